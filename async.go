@@ -0,0 +1,238 @@
+package slogjournal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrShutdown is returned by Handle after Shutdown has been called on a
+// handler with asynchronous delivery.
+var ErrShutdown = errors.New("slogjournal: handler is shut down")
+
+// asyncWriter queues entries and writes them to w from a background
+// goroutine. See Options.QueueSize. It counts the bytes it sends in
+// stats, as the bytes it accepts may still be dropped.
+type asyncWriter struct {
+	w     io.Writer
+	stats *stats
+	queue chan []byte
+	batch int           // see Options.BatchSize
+	flush time.Duration // see Options.FlushInterval
+
+	maxBytes int64        // see Options.QueueBytes
+	queued   atomic.Int64 // bytes of the entries in queue
+
+	mu     sync.RWMutex // guards closed and sending on queue
+	closed bool
+
+	abandon  atomic.Bool   // set when Shutdown gives up on the queue
+	pending  atomic.Int64  // entries queued or being sent
+	enqueued atomic.Uint64 // entries put on queue, under a read lock of mu
+	done     chan struct{} // closed when run returns
+
+	progressMu sync.Mutex
+	handled    uint64        // entries taken from queue and sent or discarded
+	progress   chan struct{} // if not nil, closed when handled grows
+}
+
+func newAsyncWriter(w io.Writer, size, batch int, flush time.Duration, maxBytes int64, s *stats) *asyncWriter {
+	if batch <= 0 {
+		batch = defaultBatchSize
+	}
+	a := &asyncWriter{
+		w:        w,
+		stats:    s,
+		queue:    make(chan []byte, size),
+		batch:    batch,
+		flush:    flush,
+		maxBytes: maxBytes,
+		done:     make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Write queues a copy of p. It drops p if the queue is full, or if queuing
+// it would exceed maxBytes.
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.stats.dropped.Add(1)
+		return 0, ErrShutdown
+	}
+	n := int64(len(p))
+	if q := a.queued.Add(n); a.maxBytes > 0 && q > a.maxBytes {
+		a.queued.Add(-n)
+		a.stats.dropped.Add(1)
+		return len(p), nil
+	}
+	a.pending.Add(1)
+	select {
+	case a.queue <- append([]byte(nil), p...):
+		a.enqueued.Add(1)
+	default:
+		a.pending.Add(-1)
+		a.queued.Add(-n)
+		a.stats.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// run sends queued entries until the queue is closed. Entries that are
+// already queued when it wakes up are sent together, see Options.BatchSize.
+// If flush is set, it waits up to flush for a batch to fill up.
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	bw, _ := a.w.(batchWriter)
+	entries := make([][]byte, 0, a.batch)
+	var timer *time.Timer
+	for b := range a.queue {
+		entries = append(entries[:0], b)
+		var deadline <-chan time.Time
+		if a.flush > 0 && len(entries) < a.batch {
+			if timer == nil {
+				timer = time.NewTimer(a.flush)
+			} else {
+				timer.Reset(a.flush)
+			}
+			deadline = timer.C
+		}
+	fill:
+		for len(entries) < a.batch {
+			select {
+			case b, ok := <-a.queue:
+				if !ok {
+					break fill
+				}
+				entries = append(entries, b)
+				continue
+			default:
+			}
+			if deadline == nil {
+				break fill
+			}
+			select {
+			case b, ok := <-a.queue:
+				if !ok {
+					break fill
+				}
+				entries = append(entries, b)
+			case <-deadline:
+				deadline = nil
+				break fill
+			}
+		}
+		if deadline != nil && !timer.Stop() {
+			// The timer fired while the batch filled up; a late flush is
+			// harmless, but a stale tick would cut the next wait short.
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if bw != nil && len(entries) > 1 && !a.abandon.Load() {
+			a.stats.bytes.Add(uint64(bw.writeBatch(entries, a.stats.recordError)))
+			a.pending.Add(-int64(len(entries)))
+		} else {
+			for _, b := range entries {
+				if !a.abandon.Load() {
+					n, err := a.w.Write(b)
+					a.stats.bytes.Add(uint64(n))
+					if err != nil {
+						a.stats.recordError(err)
+					}
+				}
+				a.pending.Add(-1)
+			}
+		}
+		var n int64
+		for _, b := range entries {
+			n += int64(len(b))
+		}
+		a.queued.Add(-n)
+		a.progressMu.Lock()
+		a.handled += uint64(len(entries))
+		if a.progress != nil {
+			close(a.progress)
+			a.progress = nil
+		}
+		a.progressMu.Unlock()
+		clear(entries)
+	}
+}
+
+// waitSent waits until the entries queued before it was called have been sent,
+// or discarded by Shutdown, or until ctx is done.
+func (a *asyncWriter) waitSent(ctx context.Context) error {
+	// Writers put entries on the queue and count them under a read lock,
+	// so the count is exact while the lock is held.
+	a.mu.Lock()
+	target := a.enqueued.Load()
+	a.mu.Unlock()
+	for {
+		a.progressMu.Lock()
+		if a.handled >= target {
+			a.progressMu.Unlock()
+			return nil
+		}
+		if a.progress == nil {
+			a.progress = make(chan struct{})
+		}
+		progress := a.progress
+		a.progressMu.Unlock()
+		select {
+		case <-progress:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// shutdown stops accepting entries and waits until the queue is drained or
+// ctx is done, whichever happens first. In the latter case it returns at
+// once, counting the entries still queued or being sent as dropped, and run
+// discards the queue in the background.
+func (a *asyncWriter) shutdown(ctx context.Context) (int, error) {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return 0, nil
+	case <-ctx.Done():
+	}
+	if a.abandon.Swap(true) {
+		// An earlier Shutdown already counted the pending entries.
+		return 0, ctx.Err()
+	}
+	n := a.pending.Load()
+	a.stats.dropped.Add(uint64(n))
+	return int(n), ctx.Err()
+}
+
+// Shutdown stops h, and every handler derived from it, from accepting
+// records and waits until all queued entries have been sent or ctx is done.
+// If ctx is done first, Shutdown returns right away, even if an entry is
+// stuck in the socket, with ctx's error and the number of entries that were
+// still queued or being sent. Those are counted as dropped and discarded in
+// the background. Records passed to Handle afterwards are dropped and
+// Handle returns ErrShutdown. Services can call it when stopping, with a
+// deadline below systemd's TimeoutStopSec.
+//
+// Shutdown does nothing if h delivers records synchronously.
+func (h *Handler) Shutdown(ctx context.Context) (dropped int, err error) {
+	a, ok := h.w.(*asyncWriter)
+	if !ok {
+		return 0, nil
+	}
+	return a.shutdown(ctx)
+}
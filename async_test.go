@@ -0,0 +1,148 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedWriter blocks every Write until gate is closed.
+type gatedWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) entries() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return bytes.Count(w.buf.Bytes(), []byte("MESSAGE="))
+}
+
+func newAsyncHandler(t *testing.T, w *gatedWriter, size int) *Handler {
+	t.Helper()
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = newAsyncWriter(w, size, 0, 0, 0, h.stats)
+	return h
+}
+
+func TestShutdown(t *testing.T) {
+	w := &gatedWriter{gate: make(chan struct{})}
+	close(w.gate)
+	h := newAsyncHandler(t, w, 16)
+	logger := slog.New(h).With("A", "B")
+	for i := 0; i < 10; i++ {
+		logger.Info("hello")
+	}
+	dropped, err := h.Shutdown(context.Background())
+	if err != nil || dropped != 0 {
+		t.Fatalf("unexpected result %d, %v", dropped, err)
+	}
+	if n := w.entries(); n != 10 {
+		t.Errorf("expected 10 entries, got %d", n)
+	}
+	if err := h.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "late", 0)); !errors.Is(err, ErrShutdown) {
+		t.Errorf("expected ErrShutdown, got %v", err)
+	}
+	if s := h.Stats(); s.Dropped != 1 {
+		t.Errorf("expected 1 dropped record, got %d", s.Dropped)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	w := &gatedWriter{gate: make(chan struct{})}
+	h := newAsyncHandler(t, w, 4)
+	for i := 0; i < 10; i++ {
+		if err := h.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "hello", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// The writer stays stuck past the deadline; Shutdown must not wait for it.
+	defer close(w.gate)
+	start := time.Now()
+	dropped, err := h.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Shutdown returned %v after its deadline", d)
+	}
+	sent := w.entries()
+	if dropped == 0 || sent != 0 {
+		t.Errorf("expected queued records to be discarded, sent %d, dropped %d", sent, dropped)
+	}
+	if s := h.Stats(); int(s.Dropped) != 10 {
+		t.Errorf("expected every record to be dropped, stats %+v", s)
+	}
+}
+
+func TestShutdownSync(t *testing.T) {
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped, err := h.Shutdown(context.Background()); dropped != 0 || err != nil {
+		t.Errorf("unexpected result %d, %v", dropped, err)
+	}
+}
+
+func TestQueueBytes(t *testing.T) {
+	w := &gatedWriter{gate: make(chan struct{})}
+	h, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad := strings.Repeat("x", 100)
+	buf := new(bytes.Buffer)
+	h.w = buf
+	slog.New(h).Info("hello", "PAD", pad)
+	// Room for five entries.
+	limit := 5*int64(buf.Len()) + 1
+	a := newAsyncWriter(w, 100, 1, 0, limit, h.stats)
+	h.w = a
+	base := h.Stats().Bytes
+	for i := 0; i < 20; i++ {
+		slog.New(h).Info("hello", "PAD", pad)
+	}
+	if q := a.queued.Load(); q > limit {
+		t.Errorf("queued %d bytes, limit is %d", q, limit)
+	}
+	close(w.gate)
+	if _, err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sent := w.entries()
+	if sent == 0 || sent > 6 {
+		t.Errorf("expected the byte limit to shed records, sent %d", sent)
+	}
+	if s := h.Stats(); int(s.Dropped)+sent != 20 {
+		t.Errorf("expected every record to be sent or dropped, sent %d, stats %+v", sent, s)
+	}
+	w.mu.Lock()
+	written := w.buf.Len()
+	w.mu.Unlock()
+	if s := h.Stats(); s.Bytes-base != uint64(written) {
+		t.Errorf("counted %d bytes, %d were written", s.Bytes-base, written)
+	}
+	if q := a.queued.Load(); q != 0 {
+		t.Errorf("expected an empty queue, %d bytes left", q)
+	}
+}
@@ -0,0 +1,36 @@
+package slogjournal
+
+import "io"
+
+// defaultBatchSize is the default of Options.BatchSize.
+const defaultBatchSize = 64
+
+// batchWriter is implemented by writers that can send several entries at
+// once, see Options.BatchSize. fail is called with the error of every
+// entry that could not be sent. writeBatch returns the number of bytes sent.
+type batchWriter interface {
+	writeBatch(entries [][]byte, fail func(error)) int
+}
+
+// writeBatch sends entries to every journal, see journalWriter.writeBatch.
+func (f fanoutWriter) writeBatch(entries [][]byte, fail func(error)) int {
+	n := 0
+	for _, w := range f {
+		n = w.writeBatch(entries, fail)
+	}
+	return n
+}
+
+// writeEach writes entries to w one at a time, as writers without support
+// for batches need.
+func writeEach(w io.Writer, entries [][]byte, fail func(error)) int {
+	written := 0
+	for _, e := range entries {
+		n, err := w.Write(e)
+		written += n
+		if err != nil {
+			fail(err)
+		}
+	}
+	return written
+}
@@ -0,0 +1,96 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// batchRecorder is a gatedWriter remembering the size of every batch.
+type batchRecorder struct {
+	gatedWriter
+	batches []int
+}
+
+func (w *batchRecorder) writeBatch(entries [][]byte, fail func(error)) int {
+	<-w.gate
+	w.mu.Lock()
+	w.batches = append(w.batches, len(entries))
+	w.mu.Unlock()
+	return writeEach(&w.gatedWriter, entries, fail)
+}
+
+func TestAsyncBatches(t *testing.T) {
+	w := &batchRecorder{gatedWriter: gatedWriter{gate: make(chan struct{})}}
+	h, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = newAsyncWriter(w, 32, 4, 0, 0, h.stats)
+	logger := slog.New(h)
+	// The first entry blocks the background goroutine, so the others queue
+	// up behind it.
+	for i := 0; i < 11; i++ {
+		logger.Info("hello")
+	}
+	close(w.gate)
+	if _, err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := w.entries(); n != 11 {
+		t.Errorf("expected 11 entries, got %d", n)
+	}
+	for _, n := range w.batches {
+		if n < 2 || n > 4 {
+			t.Errorf("unexpected batch sizes %v", w.batches)
+			break
+		}
+	}
+	if len(w.batches) == 0 {
+		t.Error("expected entries to be sent in batches")
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	w := &batchRecorder{gatedWriter: gatedWriter{gate: make(chan struct{})}}
+	close(w.gate)
+	h, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const flush = 50 * time.Millisecond
+	h.w = newAsyncWriter(w, 32, 8, flush, 0, h.stats)
+	logger := slog.New(h)
+
+	// Entries arriving within the interval are sent together.
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		logger.Info("hello")
+	}
+	for w.entries() < 3 {
+		if time.Since(start) > 10*flush {
+			t.Fatal("entries were not flushed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if d := time.Since(start); d < flush {
+		t.Errorf("entries flushed after %v, before the interval", d)
+	}
+
+	// A full batch is sent without waiting.
+	for i := 0; i < 8; i++ {
+		logger.Info("hello")
+	}
+	if _, err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := w.entries(); n != 11 {
+		t.Errorf("expected 11 entries, got %d", n)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.batches) != 2 || w.batches[0] != 3 || w.batches[1] != 8 {
+		t.Errorf("unexpected batch sizes %v", w.batches)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteBatch(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	h, err := NewHandler(&Options{Addr: addr, Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jw := journalWriters(h.w)[0]
+	_ = jw.conn.SetWriteBuffer(1 << 16)
+
+	var entries [][]byte
+	for i := 0; i < 5; i++ {
+		entries = append(entries, []byte("MESSAGE="+strconv.Itoa(i)+"\n"))
+	}
+	// An entry too large for a datagram is passed in a memfd, and does not
+	// keep the others from being sent.
+	entries[2] = []byte("MESSAGE=" + strings.Repeat("x", 1<<17) + "\n")
+	var mu sync.Mutex
+	var errs []error
+	jw.writeBatch(entries, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, 1024)
+	for i := range entries {
+		n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			if oobn == 0 {
+				t.Errorf("expected entry %d to be passed in a memfd", i)
+			}
+			continue
+		}
+		if !bytes.Equal(buf[:n], entries[i]) {
+			t.Errorf("entry %d: got %q, want %q", i, buf[:n], entries[i])
+		}
+	}
+}
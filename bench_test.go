@@ -0,0 +1,104 @@
+package slogjournal
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The benchmarks encode records without sending them, so they measure the
+// handler rather than journald. Run them with
+//
+//	go test -run '^$' -bench Handle -benchmem
+//
+// to compare allocations per record against the targets documented for
+// each case, or against sending the same fields with journal.Send from
+// github.com/coreos/go-systemd. TestHandleAllocs keeps the common cases
+// from regressing.
+
+func benchHandler(b *testing.B) *Handler {
+	b.Helper()
+	h, err := NewHandler(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	h.w = io.Discard
+	return h
+}
+
+func benchHandle(b *testing.B, h slog.Handler, r slog.Record) {
+	b.Helper()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if err := h.Handle(ctx, r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkHandleSmall logs a message with two attributes.
+// Target: 0 allocs/op.
+func BenchmarkHandleSmall(b *testing.B) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "user logged in", 0)
+	r.AddAttrs(slog.String("USER", "alice"), slog.Int("ATTEMPT", 1))
+	benchHandle(b, benchHandler(b), r)
+}
+
+// BenchmarkHandleManyAttrs logs a message with twenty attributes of mixed
+// kinds. Target: 0 allocs/op.
+func BenchmarkHandleManyAttrs(b *testing.B) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "request served", 0)
+	for range 5 {
+		r.AddAttrs(
+			slog.String("METHOD", "GET"),
+			slog.Int("STATUS", 200),
+			slog.Duration("DURATION", 1500*time.Microsecond),
+			slog.Bool("CACHED", true),
+		)
+	}
+	benchHandle(b, benchHandler(b), r)
+}
+
+// BenchmarkHandleGroups logs through a handler with preformatted
+// attributes and nested groups. Target: 0 allocs/op.
+func BenchmarkHandleGroups(b *testing.B) {
+	h := benchHandler(b).WithAttrs([]slog.Attr{slog.String("SERVICE", "api")}).WithGroup("HTTP")
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "request served", 0)
+	r.AddAttrs(slog.Group("REQUEST", slog.String("METHOD", "GET"), slog.String("PATH", "/")))
+	benchHandle(b, h, r)
+}
+
+// BenchmarkHandleOversized logs a record larger than the pooled buffers
+// keep, which has to grow a fresh buffer. Target: 3 allocs/op.
+func BenchmarkHandleOversized(b *testing.B) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "dump", 0)
+	r.AddAttrs(slog.String("PAYLOAD", strings.Repeat("x", 2*maxPooledBufferSize)))
+	benchHandle(b, benchHandler(b), r)
+}
+
+func TestHandleAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not reliable with the race detector")
+	}
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = io.Discard
+	grouped := h.WithAttrs([]slog.Attr{slog.String("SERVICE", "api")}).WithGroup("HTTP")
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "request served", 0)
+	r.AddAttrs(slog.String("METHOD", "GET"), slog.Int("STATUS", 200), slog.Duration("DURATION", time.Millisecond),
+		slog.Group("REQUEST", slog.String("PATH", "/")))
+	ctx := context.Background()
+	for _, h := range []slog.Handler{h, grouped} {
+		_ = h.Handle(ctx, r) // warm up the pools and the key cache
+		if n := testing.AllocsPerRun(100, func() { _ = h.Handle(ctx, r) }); n != 0 {
+			t.Errorf("Handle allocated %v times per record, want 0", n)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHandleAllocsSocket(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not reliable with the race detector")
+	}
+	addr := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go io.Copy(io.Discard, conn)
+	h, err := NewHandler(&Options{Addr: addr, Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jw, ok := h.w.(*journalWriter)
+	if !ok {
+		t.Fatalf("unexpected writer %T", h.w)
+	}
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "request served", 0)
+	r.AddAttrs(slog.String("METHOD", "GET"), slog.Int("STATUS", 200))
+	ctx := context.Background()
+
+	// Small records are encoded on the stack and sent from there, so Handle
+	// allocates no more than the socket write itself.
+	entry := []byte("MESSAGE=request served\n")
+	_, _ = jw.writeStack(entry)
+	send := testing.AllocsPerRun(100, func() { _, _ = jw.writeStack(entry) })
+	_ = h.Handle(ctx, r)
+	if n := testing.AllocsPerRun(100, func() { _ = h.Handle(ctx, r) }); n > send {
+		t.Errorf("Handle allocated %v times per record, sending alone %v", n, send)
+	}
+}
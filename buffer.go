@@ -0,0 +1,43 @@
+package slogjournal
+
+import "sync"
+
+// Most records fit in a few hundred bytes. Handle encodes those into an
+// array on its stack and, when they go straight to the journal socket, sends
+// them from there, see journalWriter.writeStack. Records that are expected
+// to be large start out in a pooled buffer instead, and records sent
+// anywhere else are copied into one, as passing the array to an arbitrary
+// io.Writer would move it to the heap.
+const (
+	// smallBufferSize is the size of the stack array. Records expected to
+	// take more than half of it start out in a pooled buffer.
+	smallBufferSize = 512
+	// initialBufferSize is the capacity of a new pooled buffer.
+	initialBufferSize = 1024
+	// maxPooledBufferSize is the capacity above which a buffer is left to
+	// the garbage collector after use, so that a single huge record does
+	// not pin its memory.
+	maxPooledBufferSize = 64 << 10
+)
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, initialBufferSize)
+		return &b
+	},
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+// putBuffer returns b to the pool unless it grew too large. b must not be
+// used afterwards.
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBufferSize {
+		return
+	}
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
@@ -0,0 +1,170 @@
+// Package catalog generates [journal message catalog] files for typed
+// events, so that journalctl -x shows an explanation next to every entry
+// with one of the events' MESSAGE_IDs.
+//
+// Catalogs are usually generated with go generate, by a small program that
+// lists the events of an application:
+//
+//	//go:build ignore
+//
+//	package main
+//
+//	func main() {
+//		err := catalog.Generate(os.Stdout, &catalog.Options{DefinedBy: "myapp"},
+//			myapp.UserLoggedIn{},
+//			myapp.UserLoggedOut{},
+//		)
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//
+// and a directive next to the events:
+//
+//	//go:generate sh -c "go run gen_catalog.go > myapp.catalog"
+//
+// The catalog is installed to /usr/lib/systemd/catalog/ and activated with
+// journalctl --update-catalog.
+//
+// [journal message catalog]: https://systemd.io/CATALOG/
+package catalog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// Describer is implemented by events that explain themselves. The
+// description becomes the body of the event's catalog entry, where it may
+// refer to the event's fields as @FIELD@.
+type Describer interface {
+	Description() string
+}
+
+// Options configure the generated catalog.
+type Options struct {
+	// DefinedBy is the name of the program or project defining the events,
+	// used as the Defined-By header.
+	DefinedBy string
+
+	// Support is a URL where users can find help, used as the Support
+	// header.
+	Support string
+}
+
+// Entry is a single entry of a catalog.
+type Entry struct {
+	MessageID     string
+	Subject       string
+	DefinedBy     string
+	Support       string
+	Documentation string
+	Description   string
+}
+
+// EntryFor returns the catalog entry describing ev. The message template of
+// ev is used as subject, with every {FIELD} replaced by the catalog's @FIELD@
+// syntax.
+func EntryFor(ev slogjournal.Event, opts *Options) Entry {
+	e := Entry{
+		MessageID: ev.MessageID(),
+		Subject:   convertTemplate(ev.Message()),
+	}
+	if opts != nil {
+		e.DefinedBy = opts.DefinedBy
+		e.Support = opts.Support
+	}
+	if d, ok := ev.(slogjournal.Documenter); ok {
+		e.Documentation = d.Documentation()
+	}
+	if d, ok := ev.(Describer); ok {
+		e.Description = strings.TrimSpace(d.Description())
+	}
+	return e
+}
+
+// Generate writes a catalog with an entry for each of events to w. Entries
+// are sorted by MESSAGE_ID so the output is stable. It fails if a
+// MESSAGE_ID is malformed or used by two events.
+func Generate(w io.Writer, opts *Options, events ...slogjournal.Event) error {
+	entries := make([]Entry, 0, len(events))
+	for _, ev := range events {
+		entries = append(entries, EntryFor(ev, opts))
+	}
+	slices.SortFunc(entries, func(a, b Entry) int { return strings.Compare(a.MessageID, b.MessageID) })
+	for i, e := range entries {
+		if !slogjournal.ValidMessageID(e.MessageID) {
+			return fmt.Errorf("catalog: invalid MESSAGE_ID %q", e.MessageID)
+		}
+		if i > 0 && entries[i-1].MessageID == e.MessageID {
+			return fmt.Errorf("catalog: duplicate MESSAGE_ID %s", e.MessageID)
+		}
+	}
+	return Write(w, entries...)
+}
+
+// Write writes entries to w in the catalog format. It fails without writing
+// anything if a description contains a line starting with "-- ", which
+// journalctl would read as the start of another entry.
+func Write(w io.Writer, entries ...Entry) error {
+	for _, e := range entries {
+		for _, line := range strings.Split(e.Description, "\n") {
+			if strings.HasPrefix(line, "-- ") {
+				return fmt.Errorf("catalog: description of %s contains the entry separator %q", e.MessageID, line)
+			}
+		}
+	}
+	bw := bufio.NewWriter(w)
+	for i, e := range entries {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		fmt.Fprintf(bw, "-- %s\n", e.MessageID)
+		writeHeader(bw, "Subject", e.Subject)
+		writeHeader(bw, "Defined-By", e.DefinedBy)
+		writeHeader(bw, "Support", e.Support)
+		writeHeader(bw, "Documentation", e.Documentation)
+		if e.Description != "" {
+			bw.WriteString("\n")
+			bw.WriteString(e.Description)
+			bw.WriteString("\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// writeHeader writes a header line unless value is empty. Newlines would
+// end the header early, so they are replaced with spaces.
+func writeHeader(w *bufio.Writer, key, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(w, "%s: %s\n", key, strings.ReplaceAll(value, "\n", " "))
+}
+
+// convertTemplate turns the {FIELD} placeholders of a message template into
+// the @FIELD@ placeholders journalctl substitutes in catalog entries.
+func convertTemplate(s string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(s[:start])
+		b.WriteString("@" + s[start+1:end] + "@")
+		s = s[end+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
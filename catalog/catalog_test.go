@@ -0,0 +1,80 @@
+package catalog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/systemd/slog-journal/catalog"
+)
+
+type userLoggedIn struct{ UserID int }
+
+func (userLoggedIn) MessageID() string { return "8d45620c1a4348dbb17410da57c60c66" }
+func (userLoggedIn) Message() string   { return "user {USER_ID} logged in" }
+func (userLoggedIn) Description() string {
+	return "User @USER_ID@ has successfully logged in."
+}
+
+type diskFull struct{}
+
+func (diskFull) MessageID() string { return "0a1b2c3d4e5f60718293a4b5c6d7e8f9" }
+func (diskFull) Message() string   { return "disk full" }
+func (diskFull) Documentation() string {
+	return "https://example.com/runbooks/disk-full"
+}
+
+type badID struct{}
+
+func (badID) MessageID() string { return "not-an-id" }
+func (badID) Message() string   { return "bad" }
+
+func TestGenerate(t *testing.T) {
+	var b strings.Builder
+	opts := &catalog.Options{DefinedBy: "myapp", Support: "https://example.com/support"}
+	if err := catalog.Generate(&b, opts, userLoggedIn{}, diskFull{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `-- 0a1b2c3d4e5f60718293a4b5c6d7e8f9
+Subject: disk full
+Defined-By: myapp
+Support: https://example.com/support
+Documentation: https://example.com/runbooks/disk-full
+
+-- 8d45620c1a4348dbb17410da57c60c66
+Subject: user @USER_ID@ logged in
+Defined-By: myapp
+Support: https://example.com/support
+
+User @USER_ID@ has successfully logged in.
+`
+	if b.String() != want {
+		t.Errorf("unexpected catalog:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	if err := catalog.Generate(&b, nil, badID{}); err == nil {
+		t.Error("expected an error for an invalid MESSAGE_ID")
+	}
+	if err := catalog.Generate(&b, nil, diskFull{}, diskFull{}); err == nil {
+		t.Error("expected an error for a duplicate MESSAGE_ID")
+	}
+}
+
+func TestWriteSeparator(t *testing.T) {
+	var b strings.Builder
+	e := catalog.Entry{
+		MessageID:   "8d45620c1a4348dbb17410da57c60c66",
+		Subject:     "user logged in",
+		Description: "Steps:\n-- 0a1b2c3d4e5f60718293a4b5c6d7e8f9\nmore",
+	}
+	if err := catalog.Write(&b, e); err == nil {
+		t.Error("expected an error for a description containing a separator")
+	}
+	if b.Len() != 0 {
+		t.Errorf("expected nothing to be written, got %q", b.String())
+	}
+
+	e.Description = "Run\n--verbose or -- for help"
+	if err := catalog.Write(&b, e); err != nil {
+		t.Fatal(err)
+	}
+}
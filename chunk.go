@@ -0,0 +1,180 @@
+package slogjournal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+)
+
+// RecordOverflowPolicy controls what happens to a record whose encoded size
+// exceeds Options.MaxRecordBytes.
+type RecordOverflowPolicy int
+
+const (
+	// RecordOverflowTruncate drops trailing fields until the record fits and
+	// adds a DROPPED_FIELDS field with the number of fields that were dropped.
+	// The fields the handler adds itself are always kept, and shortened if
+	// they do not fit on their own.
+	RecordOverflowTruncate RecordOverflowPolicy = iota
+	// RecordOverflowSplit sends the record as several journal entries. Every
+	// entry repeats the fields the handler adds itself and carries CHUNK_ID, CHUNK_INDEX and
+	// CHUNK_COUNT fields so the parts can be correlated again.
+	RecordOverflowSplit
+)
+
+// encodedSize returns the number of bytes f occupies on the wire.
+func (h *Handler) encodedSize(f Field) int {
+	return len(h.encodeKV(nil, f.Key, f.Value))
+}
+
+// limitRecord enforces Options.MaxRecordBytes on the encoded record b and
+// returns the entries that should be written. The first head bytes of b hold
+// the fields the handler emitted itself, which are kept in every entry; user
+// fields are dropped or split off, even if they are named like a builtin.
+func (h *Handler) limitRecord(b []byte, head int) [][]byte {
+	max := h.opts.MaxRecordBytes
+	if max <= 0 || len(b) <= max {
+		return [][]byte{b}
+	}
+	units := fieldUnits(parseFields(b[head:]))
+	if h.opts.RecordOverflow == RecordOverflowSplit {
+		return h.splitRecord(b[:head], units, max)
+	}
+	return [][]byte{h.truncateRecord(b[:head], units, max)}
+}
+
+// fieldUnits groups fs into units of a field followed by the TRUNCATED and
+// RENAMED fields describing it, which must end up in the same entry.
+func fieldUnits(fs []Field) [][]Field {
+	var units [][]Field
+	for i, f := range fs {
+		if i > 0 && (f.Key == "RENAMED" || f.Key == "TRUNCATED" && string(f.Value) == units[len(units)-1][0].Key) {
+			units[len(units)-1] = append(units[len(units)-1], f)
+			continue
+		}
+		units = append(units, fs[i:i+1:i+1])
+	}
+	return units
+}
+
+// unitSize returns the number of bytes the fields in u occupy on the wire.
+func (h *Handler) unitSize(u []Field) int {
+	n := 0
+	for _, f := range u {
+		n += h.encodedSize(f)
+	}
+	return n
+}
+
+// capHead returns a copy of the handler's fields head. If they and room more
+// bytes do not fit in max bytes, the longest values are shortened until they
+// do, and each is marked by a TRUNCATED field naming its key.
+func (h *Handler) capHead(head []byte, room, max int) []byte {
+	if len(head)+room <= max {
+		return append(make([]byte, 0, max), head...)
+	}
+	fs := parseFields(head)
+	marked := make([]bool, len(fs))
+	for i, f := range fs {
+		marked[i] = i+1 < len(fs) && fs[i+1].Key == "TRUNCATED" && string(fs[i+1].Value) == f.Key
+	}
+	truncated := make([]bool, len(fs))
+	for {
+		b := make([]byte, 0, max)
+		longest := 0
+		for i, f := range fs {
+			b = h.encodeKV(b, f.Key, f.Value)
+			if truncated[i] {
+				b = h.encodeKV(b, "TRUNCATED", []byte(f.Key))
+			}
+			if len(f.Value) > len(fs[longest].Value) {
+				longest = i
+			}
+		}
+		over := len(b) + room - max
+		if over <= 0 || len(fs) == 0 || len(fs[longest].Value) == 0 {
+			return b
+		}
+		f := &fs[longest]
+		if !truncated[longest] && !marked[longest] {
+			truncated[longest] = true
+			over += len(h.encodeKV(nil, "TRUNCATED", []byte(f.Key)))
+		}
+		keep := len(f.Value) - over
+		if keep < 0 {
+			keep = 0
+		}
+		f.Value = truncateValue(f.Value, keep)
+	}
+}
+
+// truncateRecord appends as many of units to head as fit in max bytes.
+func (h *Handler) truncateRecord(head []byte, units [][]Field, max int) []byte {
+	room := len(h.encodeKV(nil, "DROPPED_FIELDS", []byte(strconv.Itoa(len(units)))))
+	out := h.capHead(head, room, max)
+	for i, u := range units {
+		dropped := strconv.Itoa(len(units) - i)
+		reserve := len(h.encodeKV(nil, "DROPPED_FIELDS", []byte(dropped)))
+		if len(out)+h.unitSize(u)+reserve > max {
+			return h.encodeKV(out, "DROPPED_FIELDS", []byte(dropped))
+		}
+		for _, f := range u {
+			out = h.encodeKV(out, f.Key, f.Value)
+		}
+	}
+	return out
+}
+
+// splitRecord distributes units over as many entries as needed so that each,
+// including head and the chunk fields, fits in max bytes. A single unit that
+// does not fit on its own is sent in an entry of its own.
+func (h *Handler) splitRecord(head []byte, units [][]Field, max int) [][]byte {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	chunkID := []byte(hex.EncodeToString(id[:]))
+
+	// Reserve room for the chunk fields, assuming indices never need more
+	// digits than the number of fields.
+	digits := []byte(strconv.Itoa(len(units)))
+	reserve := len(h.encodeKV(nil, "CHUNK_ID", chunkID)) +
+		len(h.encodeKV(nil, "CHUNK_INDEX", digits)) +
+		len(h.encodeKV(nil, "CHUNK_COUNT", digits))
+	// Leave room for the largest unit that can share an entry with the
+	// chunk fields at all.
+	largest := 0
+	for _, u := range units {
+		if n := h.unitSize(u); n > largest && reserve+n <= max {
+			largest = n
+		}
+	}
+	head = h.capHead(head, reserve+largest, max)
+
+	var groups [][]Field
+	size := max
+	for _, u := range units {
+		n := h.unitSize(u)
+		if len(groups) == 0 || len(head)+reserve+size+n > max {
+			groups = append(groups, nil)
+			size = 0
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], u...)
+		size += n
+	}
+	if len(groups) == 0 {
+		groups = append(groups, nil)
+	}
+
+	count := []byte(strconv.Itoa(len(groups)))
+	out := make([][]byte, 0, len(groups))
+	for i, g := range groups {
+		b := append([]byte(nil), head...)
+		b = h.encodeKV(b, "CHUNK_ID", chunkID)
+		b = h.encodeKV(b, "CHUNK_INDEX", []byte(strconv.Itoa(i)))
+		b = h.encodeKV(b, "CHUNK_COUNT", count)
+		for _, f := range g {
+			b = h.encodeKV(b, f.Key, f.Value)
+		}
+		out = append(out, b)
+	}
+	return out
+}
@@ -0,0 +1,193 @@
+//go:build unix
+
+// Command slog-journal-bench floods the journal, or a fake journal socket,
+// with records and reports the achieved throughput. Against the fake socket
+// it also reports how many entries were sent as plain datagrams and how many
+// through a memfd, and how many were lost. This helps sizing record sizes,
+// rates and socket buffers for a deployment.
+//
+// Usage:
+//
+//	slog-journal-bench [flags]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func main() {
+	var (
+		count       = flag.Int("n", 100000, "number of `records` to send")
+		rate        = flag.Int("rate", 0, "records per `second`, or 0 for as fast as possible")
+		size        = flag.Int("size", 100, "size of each message in `bytes`")
+		attrs       = flag.Int("attrs", 5, "`number` of attributes per record")
+		concurrency = flag.Int("c", 1, "number of concurrent `senders`")
+		fake        = flag.Bool("fake", false, "send to a fake journal socket instead of journald")
+		namespace   = flag.String("namespace", "", "journal `namespace` to send to")
+	)
+	flag.Parse()
+
+	opts := &slogjournal.Options{Namespace: *namespace}
+	var sink *fakeJournal
+	if *fake {
+		var err error
+		if sink, err = listenFake(); err != nil {
+			fatal(err)
+		}
+		defer sink.close()
+		opts.Addr = sink.path
+	}
+	h, err := slogjournal.NewHandler(opts)
+	if err != nil {
+		fatal(err)
+	}
+
+	msg := strings.Repeat("x", *size)
+	args := make([]slog.Attr, *attrs)
+	for i := range args {
+		args[i] = slog.Int(fmt.Sprintf("ATTR_%d", i), i)
+	}
+
+	var tick <-chan time.Time
+	if *rate > 0 {
+		t := time.NewTicker(time.Second / time.Duration(*rate))
+		defer t.Stop()
+		tick = t.C
+	}
+
+	var sent, failed atomic.Int64
+	jobs := make(chan struct{})
+	var wg sync.WaitGroup
+	for range *concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				r := slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)
+				r.AddAttrs(args...)
+				if err := h.Handle(context.Background(), r); err != nil {
+					failed.Add(1)
+					continue
+				}
+				sent.Add(1)
+			}
+		}()
+	}
+
+	start := time.Now()
+	for range *count {
+		if tick != nil {
+			<-tick
+		}
+		jobs <- struct{}{}
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	fmt.Printf("sent:       %d records in %v\n", sent.Load(), elapsed.Round(time.Millisecond))
+	fmt.Printf("throughput: %.0f records/s\n", float64(sent.Load())/elapsed.Seconds())
+	fmt.Printf("errors:     %d\n", failed.Load())
+	if sink != nil {
+		datagrams, memfds := sink.wait(sent.Load())
+		fmt.Printf("received:   %d datagrams, %d memfds\n", datagrams, memfds)
+		fmt.Printf("dropped:    %d\n", sent.Load()-datagrams-memfds)
+	}
+}
+
+// fakeJournal counts the entries sent to a temporary socket.
+type fakeJournal struct {
+	dir       string
+	path      string
+	conn      *net.UnixConn
+	datagrams atomic.Int64
+	memfds    atomic.Int64
+}
+
+func listenFake() (*fakeJournal, error) {
+	dir, err := os.MkdirTemp("", "slog-journal-bench")
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	_ = conn.SetReadBuffer(8 * 1024 * 1024)
+	f := &fakeJournal{dir: dir, path: path, conn: conn}
+	go f.receive()
+	return f, nil
+}
+
+func (f *fakeJournal) receive() {
+	buf := make([]byte, 256*1024)
+	oob := make([]byte, 1024)
+	for {
+		_, oobn, _, _, err := f.conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			return
+		}
+		if oobn > 0 {
+			f.memfds.Add(1)
+			closeRights(oob[:oobn])
+		} else {
+			f.datagrams.Add(1)
+		}
+	}
+}
+
+// wait waits until n entries arrived or nothing arrived for a second, and
+// returns the counts.
+func (f *fakeJournal) wait(n int64) (datagrams, memfds int64) {
+	last := int64(-1)
+	for {
+		datagrams, memfds = f.datagrams.Load(), f.memfds.Load()
+		if datagrams+memfds >= n || datagrams+memfds == last {
+			return datagrams, memfds
+		}
+		last = datagrams + memfds
+		time.Sleep(time.Second)
+	}
+}
+
+func (f *fakeJournal) close() {
+	f.conn.Close()
+	os.RemoveAll(f.dir)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "slog-journal-bench:", err)
+	os.Exit(1)
+}
+
+// closeRights closes the file descriptors passed in the control message oob.
+func closeRights(oob []byte) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return
+	}
+	for _, m := range msgs {
+		fds, err := syscall.ParseUnixRights(&m)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			syscall.Close(fd)
+		}
+	}
+}
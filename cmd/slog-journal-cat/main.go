@@ -0,0 +1,130 @@
+// Command slog-journal-cat reads structured log lines from standard input and
+// forwards them to the systemd journal as native entries. It is a structured
+// counterpart of systemd-cat(1): JSON objects and logfmt lines are split into
+// journal fields instead of being logged as plain text.
+//
+// Usage:
+//
+//	some-program | slog-journal-cat [flags]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func main() {
+	var (
+		identifier = flag.String("t", "", "`identifier` to send as SYSLOG_IDENTIFIER (default: the program name)")
+		namespace  = flag.String("namespace", "", "journal `namespace` to log to")
+		format     = flag.String("format", "auto", "input `format`: json, logfmt, or auto to detect it per line")
+		priority   = flag.String("p", "info", "default `priority` for lines without a level")
+		levelKey   = flag.String("level-key", "", "comma-separated `keys` holding the level (default: level,lvl,severity)")
+		messageKey = flag.String("message-key", "", "comma-separated `keys` holding the message (default: msg,message)")
+		timeKey    = flag.String("time-key", "", "comma-separated `keys` holding the time (default: time,ts,timestamp)")
+	)
+	flag.Parse()
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	level, err := slogjournal.ParseLevel(*priority)
+	if err != nil {
+		fatal(err)
+	}
+	h, err := slogjournal.NewHandler(&slogjournal.Options{
+		Level:      slog.LevelDebug - 4,
+		Namespace:  *namespace,
+		Identifier: *identifier,
+	})
+	if err != nil {
+		fatal(err)
+	}
+	opts := &slogjournal.ConvertOptions{
+		MessageKeys:  splitKeys(*messageKey),
+		LevelKeys:    splitKeys(*levelKey),
+		TimeKeys:     splitKeys(*timeKey),
+		DefaultLevel: level,
+	}
+
+	var w io.WriteCloser
+	switch *format {
+	case "json":
+		w = slogjournal.NewJSONWriter(h, opts)
+	case "logfmt":
+		w = slogjournal.NewLogfmtWriter(h, opts)
+	case "auto":
+		w = &autoWriter{
+			json:   slogjournal.NewJSONWriter(h, opts),
+			logfmt: slogjournal.NewLogfmtWriter(h, opts),
+		}
+	default:
+		fatal(fmt.Errorf("unknown format %q", *format))
+	}
+	if err := copyLines(w, os.Stdin); err != nil {
+		fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		fatal(err)
+	}
+}
+
+// autoWriter sends lines that look like JSON objects to json and all others
+// to logfmt. It expects to be written one complete line at a time.
+type autoWriter struct {
+	json, logfmt io.WriteCloser
+}
+
+func (w *autoWriter) Write(p []byte) (int, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(p), []byte("{")) {
+		return w.json.Write(p)
+	}
+	return w.logfmt.Write(p)
+}
+
+func (w *autoWriter) Close() error {
+	if err := w.json.Close(); err != nil {
+		return err
+	}
+	return w.logfmt.Close()
+}
+
+// copyLines writes r to w one line at a time.
+func copyLines(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, werr := w.Write(line); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func splitKeys(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "slog-journal-cat:", err)
+	os.Exit(1)
+}
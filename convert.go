@@ -0,0 +1,347 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConvertOptions configure the writers that convert the structured output of
+// other logging libraries into records, such as [JSONWriter].
+type ConvertOptions struct {
+	// MessageKeys are the keys holding the message, in order of preference.
+	// The default is "msg", "message".
+	MessageKeys []string
+
+	// LevelKeys are the keys holding the level, in order of preference.
+	// The default is "level", "lvl", "severity". Levels are parsed with
+	// [ParseConvertedLevel].
+	LevelKeys []string
+
+	// TimeKeys are the keys holding the timestamp, in order of preference.
+	// The default is "time", "ts", "timestamp".
+	TimeKeys []string
+
+	// DefaultLevel is used for lines without a recognised level.
+	DefaultLevel slog.Level
+}
+
+func (o *ConvertOptions) messageKeys() []string {
+	if o != nil && o.MessageKeys != nil {
+		return o.MessageKeys
+	}
+	return []string{"msg", "message"}
+}
+
+func (o *ConvertOptions) levelKeys() []string {
+	if o != nil && o.LevelKeys != nil {
+		return o.LevelKeys
+	}
+	return []string{"level", "lvl", "severity"}
+}
+
+func (o *ConvertOptions) timeKeys() []string {
+	if o != nil && o.TimeKeys != nil {
+		return o.TimeKeys
+	}
+	return []string{"time", "ts", "timestamp"}
+}
+
+func (o *ConvertOptions) defaultLevel() slog.Level {
+	if o != nil {
+		return o.DefaultLevel
+	}
+	return slog.LevelInfo
+}
+
+// ParseConvertedLevel parses a level as written by common logging libraries.
+// Besides everything [ParseLevel] accepts it understands "trace", "fatal",
+// "panic" and "dpanic", and the numeric levels of bunyan and pino (10 for
+// trace up to 60 for fatal).
+func ParseConvertedLevel(s string) (slog.Level, bool) {
+	if l, err := ParseLevel(s); err == nil {
+		return l, true
+	}
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "trace":
+		return slog.LevelDebug - 4, true
+	case "fatal", "dpanic":
+		return LevelCritical, true
+	case "panic":
+		return LevelAlert, true
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 10 {
+		return 0, false
+	}
+	switch {
+	case n >= 60:
+		return LevelCritical, true
+	case n >= 50:
+		return slog.LevelError, true
+	case n >= 40:
+		return slog.LevelWarn, true
+	case n >= 30:
+		return slog.LevelInfo, true
+	case n >= 20:
+		return slog.LevelDebug, true
+	default:
+		return slog.LevelDebug - 4, true
+	}
+}
+
+// parseConvertedTime parses an RFC 3339 timestamp or a unix time in seconds,
+// milliseconds, microseconds or nanoseconds. The unit is guessed from the
+// magnitude, which is unambiguous for times after 2001.
+func parseConvertedTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	unit := time.Second
+	switch {
+	case f > 1e18:
+		unit = time.Nanosecond
+	case f > 1e15:
+		unit = time.Microsecond
+	case f > 1e12:
+		unit = time.Millisecond
+	}
+	perSec := int64(time.Second / unit)
+	// Integers are parsed exactly, since a float64 cannot hold nanoseconds.
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n/perSec, n%perSec*int64(unit)), true
+	}
+	whole, frac := math.Modf(f)
+	n := int64(whole)
+	return time.Unix(n/perSec, n%perSec*int64(unit)+int64(frac*float64(unit))), true
+}
+
+// JSONWriter is an [io.Writer] that parses newline-delimited JSON objects, as
+// written by zerolog, zap, bunyan and similar libraries, and passes each of
+// them to a handler as a record. The level, message and time keys are mapped
+// to the record's level, message and time; all other keys become attributes
+// with upper-cased names, and nested objects become groups. Lines that are
+// not JSON objects are logged verbatim at the default level.
+// It is safe for concurrent use.
+type JSONWriter struct {
+	lineWriter
+	h    slog.Handler
+	opts *ConvertOptions
+}
+
+// NewJSONWriter returns a JSONWriter passing records to h. If opts is nil,
+// the default options are used.
+func NewJSONWriter(h slog.Handler, opts *ConvertOptions) *JSONWriter {
+	w := &JSONWriter{h: h, opts: opts}
+	w.emit = w.emitLine
+	return w
+}
+
+// Write converts every complete line in p. An incomplete trailing line is
+// kept until the rest of it is written or Close is called.
+func (w *JSONWriter) Write(p []byte) (int, error) {
+	return w.lineWriter.Write(p)
+}
+
+// Close converts any incomplete line that is still buffered.
+func (w *JSONWriter) Close() error {
+	return w.lineWriter.Close()
+}
+
+func (w *JSONWriter) emitLine(line []byte) error {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil
+	}
+	var obj map[string]any
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.UseNumber()
+	if err := d.Decode(&obj); err != nil || obj == nil {
+		return emitConverted(w.h, w.opts, string(line), nil)
+	}
+	return emitConverted(w.h, w.opts, "", obj)
+}
+
+// emitConverted passes the converted fields in obj to h. If obj is nil, raw
+// is logged as the message at the default level.
+func emitConverted(h slog.Handler, opts *ConvertOptions, raw string, obj map[string]any) error {
+	ctx := context.Background()
+	level := opts.defaultLevel()
+	t := time.Now()
+	msg := raw
+	if obj != nil {
+		if v, ok := takeKey(obj, opts.levelKeys()); ok {
+			if l, ok := ParseConvertedLevel(v); ok {
+				level = l
+			}
+		}
+		if v, ok := takeKey(obj, opts.timeKeys()); ok {
+			if ts, ok := parseConvertedTime(v); ok {
+				t = ts
+			}
+		}
+		msg, _ = takeKey(obj, opts.messageKeys())
+	}
+	if !h.Enabled(ctx, level) {
+		return nil
+	}
+	r := slog.NewRecord(t, level, msg, 0)
+	r.AddAttrs(convertedAttrs(obj)...)
+	return h.Handle(ctx, r)
+}
+
+// takeKey removes the first of keys present in obj and returns its value as
+// a string.
+func takeKey(obj map[string]any, keys []string) (string, bool) {
+	for _, k := range keys {
+		v, ok := obj[k]
+		if !ok {
+			continue
+		}
+		delete(obj, k)
+		if s, ok := v.(string); ok {
+			return s, true
+		}
+		b, _ := json.Marshal(v)
+		return string(b), true
+	}
+	return "", false
+}
+
+// convertedAttrs turns a decoded object into attributes, sorted by key.
+func convertedAttrs(obj map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(obj))
+	for _, k := range keys {
+		v := obj[k]
+		key := SanitizeKey(k)
+		if key == "" {
+			continue
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			attrs = append(attrs, slog.Attr{Key: key, Value: slog.GroupValue(convertedAttrs(v)...)})
+		case string:
+			attrs = append(attrs, slog.String(key, v))
+		case json.Number:
+			attrs = append(attrs, slog.String(key, v.String()))
+		case bool:
+			attrs = append(attrs, slog.Bool(key, v))
+		case nil:
+			attrs = append(attrs, slog.String(key, ""))
+		default:
+			b, _ := json.Marshal(v)
+			attrs = append(attrs, slog.String(key, string(b)))
+		}
+	}
+	return attrs
+}
+
+// LogfmtWriter is an [io.Writer] that parses logfmt lines, such as
+// `level=info msg="user logged in" user=42`, and passes each of them to a
+// handler as a record, mapping keys like [JSONWriter] does. Lines without
+// any key=value pair are logged verbatim at the default level.
+// It is safe for concurrent use.
+type LogfmtWriter struct {
+	lineWriter
+	h    slog.Handler
+	opts *ConvertOptions
+}
+
+// NewLogfmtWriter returns a LogfmtWriter passing records to h. If opts is
+// nil, the default options are used.
+func NewLogfmtWriter(h slog.Handler, opts *ConvertOptions) *LogfmtWriter {
+	w := &LogfmtWriter{h: h, opts: opts}
+	w.emit = w.emitLine
+	return w
+}
+
+// Write converts every complete line in p. An incomplete trailing line is
+// kept until the rest of it is written or Close is called.
+func (w *LogfmtWriter) Write(p []byte) (int, error) {
+	return w.lineWriter.Write(p)
+}
+
+// Close converts any incomplete line that is still buffered.
+func (w *LogfmtWriter) Close() error {
+	return w.lineWriter.Close()
+}
+
+func (w *LogfmtWriter) emitLine(line []byte) error {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil
+	}
+	obj, ok := parseLogfmt(string(line))
+	if !ok {
+		return emitConverted(w.h, w.opts, string(line), nil)
+	}
+	return emitConverted(w.h, w.opts, "", obj)
+}
+
+// parseLogfmt splits a logfmt line into its pairs. Keys without a value are
+// set to "true". It reports false if the line contains no key=value pair.
+func parseLogfmt(line string) (map[string]any, bool) {
+	obj := make(map[string]any)
+	pairs := false
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			break
+		}
+		i := strings.IndexAny(line, "= \t")
+		if i < 0 {
+			obj[line] = "true"
+			break
+		}
+		key := line[:i]
+		if line[i] != '=' {
+			obj[key] = "true"
+			line = line[i:]
+			continue
+		}
+		line = line[i+1:]
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			end := 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, false
+			}
+			v, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				v = line[1:end]
+			}
+			value, line = v, line[end+1:]
+		} else {
+			j := strings.IndexAny(line, " \t")
+			if j < 0 {
+				j = len(line)
+			}
+			value, line = line[:j], line[j:]
+		}
+		if key == "" {
+			return nil, false
+		}
+		obj[key] = value
+		pairs = true
+	}
+	return obj, pairs
+}
@@ -0,0 +1,122 @@
+package slogjournal
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestJSONWriter(t *testing.T) {
+	ew := &entryWriter{}
+	h, err := NewHandler(&Options{Level: slog.LevelDebug, TimestampFormat: TimestampUnixMicro})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = ew
+
+	w := NewJSONWriter(h, nil)
+	fmt.Fprintln(w, `{"level":"warn","time":"2024-01-02T03:04:05Z","msg":"zerolog style","request-id":"abc","k8s.io/zone":"a","count":3,"http":{"method":"GET"}}`)
+	fmt.Fprintln(w, `{"level":50,"time":1704164645000,"msg":"bunyan style","v":0}`)
+	fmt.Fprintln(w, `not json`)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ew.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(ew.entries))
+	}
+
+	tests := []map[string]string{
+		{"MESSAGE": "zerolog style", "PRIORITY": "4", "REQUEST_ID": "abc", "K8S_IOZONE": "a", "COUNT": "3", "HTTP_METHOD": "GET", "SYSLOG_TIMESTAMP": "1704164645000000"},
+		{"MESSAGE": "bunyan style", "PRIORITY": "3", "V": "0", "SYSLOG_TIMESTAMP": "1704164645000000"},
+		{"MESSAGE": "not json", "PRIORITY": "6"},
+	}
+	for i, want := range tests {
+		kv, err := deserializeKeyValue(bytes.NewReader(ew.entries[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range want {
+			if kv[k] != v {
+				t.Errorf("entry %d: %s=%q, want %q", i, k, kv[k], v)
+			}
+		}
+	}
+}
+
+func TestParseConvertedLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"trace": slog.LevelDebug - 4,
+		"INFO":  slog.LevelInfo,
+		"fatal": LevelCritical,
+		"panic": LevelAlert,
+		"30":    slog.LevelInfo,
+		"60":    LevelCritical,
+		"3":     slog.LevelError,
+	}
+	for in, want := range tests {
+		got, ok := ParseConvertedLevel(in)
+		if !ok || got != want {
+			t.Errorf("ParseConvertedLevel(%q) = %v, %v, want %v", in, got, ok, want)
+		}
+	}
+	if _, ok := ParseConvertedLevel("verbose"); ok {
+		t.Error("expected unknown level to fail")
+	}
+}
+
+func TestLogfmtWriter(t *testing.T) {
+	ew := &entryWriter{}
+	h, err := NewHandler(&Options{Level: slog.LevelDebug, TimestampFormat: TimestampUnixMicro})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = ew
+
+	w := NewLogfmtWriter(h, nil)
+	fmt.Fprintln(w, `ts=2024-01-02T03:04:05Z level=error msg="user \"bob\" failed" user.id=42 retry`)
+	fmt.Fprintln(w, `just some text`)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ew.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(ew.entries))
+	}
+
+	tests := []map[string]string{
+		{"MESSAGE": `user "bob" failed`, "PRIORITY": "3", "USER_ID": "42", "RETRY": "true", "SYSLOG_TIMESTAMP": "1704164645000000"},
+		{"MESSAGE": "just some text", "PRIORITY": "6"},
+	}
+	for i, want := range tests {
+		kv, err := deserializeKeyValue(bytes.NewReader(ew.entries[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range want {
+			if kv[k] != v {
+				t.Errorf("entry %d: %s=%q, want %q", i, k, kv[k], v)
+			}
+		}
+	}
+}
+
+func TestParseConvertedTime(t *testing.T) {
+	want := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	for in, want := range map[string]time.Time{
+		"2024-01-02T03:04:05.123456789Z": want,
+		"1704164645":                     want.Truncate(time.Second),
+		"1704164645.5":                   want.Truncate(time.Second).Add(500 * time.Millisecond),
+		"1704164645123":                  want.Truncate(time.Millisecond),
+		"1704164645123456":               want.Truncate(time.Microsecond),
+		"1704164645123456789":            want,
+		"1704164645123.5":                want.Truncate(time.Millisecond).Add(500 * time.Microsecond),
+	} {
+		if got, ok := parseConvertedTime(in); !ok || !got.Equal(want) {
+			t.Errorf("parseConvertedTime(%q) = %v, %v, want %v", in, got, ok, want)
+		}
+	}
+	if _, ok := parseConvertedTime("yesterday"); ok {
+		t.Error("expected an invalid time to fail")
+	}
+}
@@ -0,0 +1,84 @@
+package slogjournal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Field is a single field of a journal entry.
+type Field struct {
+	Key   string
+	Value []byte
+}
+
+// AppendField appends the field key=value to b in the framing of the
+// [native protocol]. Values containing a newline are written in the
+// length-prefixed binary form. The key is not validated.
+//
+// [native protocol]: https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+func AppendField(b []byte, key string, value []byte) []byte {
+	return appendField(b, key, value, false)
+}
+
+// appendField is AppendField with the option to force the binary form.
+func appendField(b []byte, key string, value []byte, binaryForm bool) []byte {
+	b = append(b, key...)
+	if binaryForm || bytes.IndexByte(value, '\n') != -1 {
+		b = append(b, '\n')
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+	} else {
+		b = append(b, '=')
+	}
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// Encode encodes fields as a single journal entry, see [AppendField].
+func Encode(fields []Field) []byte {
+	var b []byte
+	for _, f := range fields {
+		b = AppendField(b, f.Key, f.Value)
+	}
+	return b
+}
+
+// Decode parses a single journal entry in the native protocol format. The
+// returned values alias b. On error, the fields decoded before the malformed
+// one are returned along with the error.
+func Decode(b []byte) ([]Field, error) {
+	var fs []Field
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		if i < 0 {
+			return fs, errors.New("slogjournal: field without value")
+		}
+		if i == 0 {
+			return fs, errors.New("slogjournal: empty field name")
+		}
+		key := string(b[:i])
+		if b[i] == '=' {
+			b = b[i+1:]
+			j := bytes.IndexByte(b, '\n')
+			if j < 0 {
+				return fs, fmt.Errorf("slogjournal: field %s is not terminated by a newline", key)
+			}
+			fs = append(fs, Field{Key: key, Value: b[:j:j]})
+			b = b[j+1:]
+			continue
+		}
+		b = b[i+1:]
+		if len(b) < 8 {
+			return fs, fmt.Errorf("slogjournal: field %s is missing its length", key)
+		}
+		n := binary.LittleEndian.Uint64(b)
+		b = b[8:]
+		if n >= uint64(len(b)) || b[n] != '\n' {
+			return fs, fmt.Errorf("slogjournal: binary field %s is not terminated by a newline", key)
+		}
+		fs = append(fs, Field{Key: key, Value: b[:n:n]})
+		b = b[n+1:]
+	}
+	return fs, nil
+}
@@ -0,0 +1,60 @@
+package slogjournal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func FuzzEncodeDecode(f *testing.F) {
+	f.Add("MESSAGE", []byte("Hello, World!"), "KEY", []byte("a\nb"))
+	f.Add("A", []byte(""), "B", []byte("\n"))
+	f.Add("NUL", []byte("\x00\x00"), "EQ", []byte("a=b\n=c"))
+	f.Add("BIG", bytes.Repeat([]byte("x\n"), 1<<12), "TRAILING", []byte("x\n\n"))
+	f.Fuzz(func(t *testing.T, k1 string, v1 []byte, k2 string, v2 []byte) {
+		for _, k := range []string{k1, k2} {
+			if k == "" || strings.ContainsAny(k, "=\n") {
+				t.Skip("not a valid key")
+			}
+		}
+		in := []Field{{Key: k1, Value: v1}, {Key: k2, Value: v2}}
+		out, err := Decode(Encode(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != len(in) {
+			t.Fatalf("decoded %d fields, want %d", len(out), len(in))
+		}
+		for i := range in {
+			if out[i].Key != in[i].Key || !bytes.Equal(out[i].Value, in[i].Value) {
+				t.Errorf("field %d: got %q=%q, want %q=%q", i, out[i].Key, out[i].Value, in[i].Key, in[i].Value)
+			}
+		}
+	})
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte("MESSAGE=hello\n"))
+	f.Add([]byte("KEY\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n"))
+	f.Add([]byte("KEY\n\xff\xff\xff\xff\xff\xff\xff\xff"))
+	f.Add([]byte("=\n"))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		fs, err := Decode(b)
+		if err != nil {
+			return
+		}
+		// Whatever decodes successfully must survive a round trip.
+		again, err := Decode(Encode(fs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(again) != len(fs) {
+			t.Fatalf("decoded %d fields, want %d", len(again), len(fs))
+		}
+		for i := range fs {
+			if again[i].Key != fs[i].Key || !bytes.Equal(again[i].Value, fs[i].Value) {
+				t.Errorf("field %d changed in round trip", i)
+			}
+		}
+	})
+}
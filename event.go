@@ -0,0 +1,103 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MessageIDKey is the journal field identifying the type of an event.
+const MessageIDKey = "MESSAGE_ID"
+
+// Event is implemented by structs describing one type of event, for use
+// with [Handler.LogEvent]. Every exported field of the struct is sent as a
+// journal field whose name is the field's name in upper snake case, so a
+// field UserID becomes USER_ID, unless a journal struct tag names it
+// otherwise, see [Handler].
+//
+//	type UserLoggedIn struct {
+//		UserID int
+//		Method string
+//	}
+//
+//	func (UserLoggedIn) MessageID() string { return "8d45620c1a4348dbb17410da57c60c66" }
+//	func (UserLoggedIn) Message() string   { return "user {USER_ID} logged in via {METHOD}" }
+type Event interface {
+	// MessageID returns the 128-bit identifier of the event type as 32
+	// lowercase hexadecimal digits, as generated by systemd-id128 new or
+	// returned by [MessageID.String]. It is sent as the MESSAGE_ID field.
+	MessageID() string
+
+	// Message returns the message template of the event. Every {KEY} in it
+	// is replaced with the value of the field named KEY.
+	Message() string
+}
+
+// DocumentationKey is the journal field holding a documentation URL.
+const DocumentationKey = "DOCUMENTATION"
+
+// Documenter is implemented by events that link to documentation, such as
+// a runbook. [Handler.LogEvent] sends the URL as the DOCUMENTATION field,
+// replacing the handler's own.
+type Documenter interface {
+	Documentation() string
+}
+
+// WithDocumentation returns a new Handler that sends url as the
+// DOCUMENTATION field of every record, instead of the receiver's.
+func (h *Handler) WithDocumentation(url string) *Handler {
+	h2 := *h
+	h2.documentation = []byte(url)
+	return &h2
+}
+
+// LogEvent sends ev as a record at level. The fields of ev are sent outside
+// of any group of h, so their names are the same wherever the event is
+// logged, and can be searched for with journalctl MESSAGE_ID=….
+func (h *Handler) LogEvent(ctx context.Context, level slog.Level, ev Event) error {
+	if !h.Enabled(ctx, level) {
+		return nil
+	}
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+
+	attrs := eventAttrs(ev)
+	r := slog.NewRecord(time.Now(), level, renderTemplate(ev.Message(), attrLookup(attrs)), pcs[0])
+	r.AddAttrs(slog.String(MessageIDKey, ev.MessageID()))
+	r.AddAttrs(attrs...)
+
+	h2 := *h
+	h2.prefix = ""
+	h2.groups = nil
+	if d, ok := ev.(Documenter); ok {
+		h2.documentation = []byte(d.Documentation())
+	}
+	return h2.Handle(ctx, r)
+}
+
+// eventAttrs returns the exported fields of the struct ev as attributes.
+func eventAttrs(ev any) []slog.Attr {
+	attrs, _ := structAttrs(ev, false)
+	return attrs
+}
+
+// fieldName turns a Go identifier such as HTTPStatus into a journal field
+// name such as HTTP_STATUS.
+func fieldName(name string) string {
+	rs := []rune(name)
+	var b strings.Builder
+	for i, r := range rs {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := rs[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				unicode.IsUpper(prev) && i+1 < len(rs) && unicode.IsLower(rs[i+1]) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(r)
+	}
+	return SanitizeKey(b.String())
+}
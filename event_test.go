@@ -0,0 +1,114 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+type userLoggedIn struct {
+	UserID     int
+	HTTPMethod string
+	internal   bool
+}
+
+func (userLoggedIn) MessageID() string { return "8d45620c1a4348dbb17410da57c60c66" }
+func (userLoggedIn) Message() string   { return "user {USER_ID} logged in via {HTTP_METHOD} {UNKNOWN}" }
+
+func TestLogEvent(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	g := h.WithGroup("G").(*Handler)
+	if err := g.LogEvent(context.TODO(), slog.LevelInfo, userLoggedIn{UserID: 42, HTTPMethod: "POST"}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"MESSAGE":     "user 42 logged in via POST {UNKNOWN}",
+		"MESSAGE_ID":  "8d45620c1a4348dbb17410da57c60c66",
+		"USER_ID":     "42",
+		"HTTP_METHOD": "POST",
+	}
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("expected %s=%q, got %q", k, v, m[k])
+		}
+	}
+	if _, ok := m["INTERNAL"]; ok {
+		t.Error("unexpected unexported field")
+	}
+	if m["CODE_FUNC"] == "" {
+		t.Error("expected CODE_FUNC")
+	}
+
+	buf.Reset()
+	if err := h.LogEvent(context.TODO(), slog.LevelDebug, &userLoggedIn{}); err != nil || buf.Len() != 0 {
+		t.Errorf("expected disabled level to be dropped, got %v, %q", err, buf.Bytes())
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	for name, want := range map[string]string{
+		"UserID":     "USER_ID",
+		"HTTPStatus": "HTTP_STATUS",
+		"Method":     "METHOD",
+		"ID":         "ID",
+	} {
+		if got := fieldName(name); got != want {
+			t.Errorf("fieldName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+type diskFull struct{}
+
+func (diskFull) MessageID() string     { return "0a1b2c3d4e5f60718293a4b5c6d7e8f9" }
+func (diskFull) Message() string       { return "disk full" }
+func (diskFull) Documentation() string { return "https://example.com/runbooks/disk-full" }
+
+func TestDocumentation(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{Documentation: "https://example.com/runbooks"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+
+	slog.New(h).Info("hello")
+	m, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m[DocumentationKey] != "https://example.com/runbooks" {
+		t.Errorf("unexpected fields %v", m)
+	}
+
+	buf.Reset()
+	slog.New(h.WithDocumentation("https://example.com/runbooks/http")).WithGroup("G").Info("hello")
+	if m, _ := deserializeKeyValue(buf); m[DocumentationKey] != "https://example.com/runbooks/http" {
+		t.Errorf("unexpected fields %v", m)
+	}
+
+	buf.Reset()
+	if err := h.LogEvent(context.TODO(), slog.LevelError, diskFull{}); err != nil {
+		t.Fatal(err)
+	}
+	fs := parseFields(buf.Bytes())
+	var docs []string
+	for _, f := range fs {
+		if f.Key == DocumentationKey {
+			docs = append(docs, string(f.Value))
+		}
+	}
+	if len(docs) != 1 || docs[0] != "https://example.com/runbooks/disk-full" {
+		t.Errorf("unexpected documentation %q", docs)
+	}
+}
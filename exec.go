@@ -0,0 +1,106 @@
+package slogjournal
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// ForwardOutput sends every line the command cmd writes to its standard
+// output to h at slog.LevelInfo and every line written to standard error at
+// slog.LevelWarn, using the native protocol. If identifier is not empty it
+// is used as SYSLOG_IDENTIFIER, so the child's lines are attributed to it.
+// It must be called before cmd is started. The returned function logs any
+// incomplete last lines and must be called after cmd.Wait returns.
+func ForwardOutput(cmd *exec.Cmd, h *Handler, identifier string) (flush func() error) {
+	if identifier != "" {
+		h = h.WithIdentifier(identifier)
+	}
+	stdout := NewPriorityWriter(h, slog.LevelInfo)
+	stderr := NewPriorityWriter(h, slog.LevelWarn)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return func() error {
+		return errors.Join(stdout.Close(), stderr.Close())
+	}
+}
+
+// streamSocket is the socket journald accepts stream connections on.
+const streamSocket = "/run/systemd/journal/stdout"
+
+// StreamOptions configure a journal stream, see [OpenStream].
+type StreamOptions struct {
+	// Identifier is the SYSLOG_IDENTIFIER of the stream's entries.
+	Identifier string
+
+	// Level is the level of lines without a <N> prefix. It is sent as the
+	// priority given by LevelToPriority. The default is slog.LevelInfo.
+	Level slog.Level
+
+	// LevelPrefix makes journald parse <N> prefixes at the start of lines,
+	// as written by sd-daemon.h, to set the priority of single lines.
+	LevelPrefix bool
+
+	// Namespace selects the journal namespace. The default namespace is
+	// used if empty.
+	Namespace string
+
+	// Addr is the path of the stream socket. It overrides Namespace and is
+	// mostly useful in tests.
+	Addr string
+}
+
+// OpenStream connects to journald's stream protocol, the way systemd
+// connects the standard output of services to the journal, and returns the
+// connection as a file. Passing it as Stdout or Stderr of an [exec.Cmd]
+// hands it to the child process directly, without copying in this process,
+// and the child sees a journal stream in $JOURNAL_STREAM if it is set from
+// [StreamEnv]. The caller should close the file once the child has started.
+func OpenStream(opts *StreamOptions) (*os.File, error) {
+	var o StreamOptions
+	if opts != nil {
+		o = *opts
+	}
+	path := o.Addr
+	switch {
+	case path != "":
+	case o.Namespace != "":
+		path = filepath.Join(filepath.Dir(journalSocket(o.Namespace)), "stdout")
+	default:
+		path = streamSocket
+	}
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// The header is the identifier, the unit ID, the priority, and whether
+	// to parse level prefixes and forward to syslog, kmsg and the console.
+	levelPrefix := 0
+	if o.LevelPrefix {
+		levelPrefix = 1
+	}
+	header := fmt.Sprintf("%s\n\n%d\n%d\n0\n0\n0\n", o.Identifier, LevelToPriority(o.Level), levelPrefix)
+	if _, err := conn.Write([]byte(header)); err != nil {
+		return nil, err
+	}
+	if err := conn.CloseRead(); err != nil {
+		return nil, err
+	}
+	return conn.File()
+}
+
+// StreamEnv returns the JOURNAL_STREAM environment variable for a child
+// process whose standard error is f, as returned by [OpenStream], so the
+// child can detect that it logs to the journal, see [StderrIsJournal].
+func StreamEnv(f *os.File) (string, error) {
+	id, err := fileID(f)
+	if err != nil {
+		return "", err
+	}
+	return "JOURNAL_STREAM=" + id, nil
+}
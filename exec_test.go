@@ -0,0 +1,98 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestForwardOutput(t *testing.T) {
+	w := &entryWriter{}
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = w
+
+	cmd := exec.Command("sh", "-c", "echo out; echo err >&2; printf partial")
+	flush := ForwardOutput(cmd, h, "child")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	w.mu.Lock()
+	for _, e := range w.entries {
+		m := map[string]string{}
+		for _, f := range parseFields(e) {
+			m[f.Key] = string(f.Value)
+		}
+		if m["SYSLOG_IDENTIFIER"] != "child" {
+			t.Errorf("unexpected identifier in %v", m)
+		}
+		got[m["MESSAGE"]] = m["PRIORITY"]
+	}
+	w.mu.Unlock()
+	want := map[string]string{"out": "6", "err": "4", "partial": "6"}
+	for msg, prio := range want {
+		if got[msg] != prio {
+			t.Errorf("expected %q at priority %s, got %v", msg, prio, got)
+		}
+	}
+}
+
+func TestOpenStream(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "stdout")
+	l, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var lines []string
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			lines = append(lines, s.Text())
+		}
+		received <- lines
+	}()
+
+	f, err := OpenStream(&StreamOptions{Identifier: "child", Level: slog.LevelWarn, LevelPrefix: true, Addr: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := StreamEnv(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(env, "JOURNAL_STREAM=") {
+		t.Errorf("unexpected environment %q", env)
+	}
+	cmd := exec.Command("sh", "-c", "echo hello")
+	cmd.Stdout = f
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	want := []string{"child", "", "4", "1", "0", "0", "0", "hello"}
+	if lines := <-received; !slices.Equal(lines, want) {
+		t.Errorf("expected %q, got %q", want, lines)
+	}
+}
@@ -0,0 +1,91 @@
+package slogjournal
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// priorityNames are the syslog names of the journal priorities.
+var priorityNames = [...]string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// textWriter formats entries as single structured lines, for platforms
+// without a journal. Each line has the form
+//
+//	2006-01-02T15:04:05.000Z07:00 identifier[pid] priority: message KEY=value ...
+type textWriter struct {
+	w   io.Writer
+	now func() time.Time
+}
+
+// Write decodes the entry p and writes it to w as a single line.
+func (t *textWriter) Write(p []byte) (int, error) {
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+	line := now().AppendFormat(nil, "2006-01-02T15:04:05.000Z07:00")
+	line = append(line, ' ')
+	line = appendTextEntry(line, parseFields(p), true)
+	line = append(line, '\n')
+	if _, err := t.w.Write(line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// appendTextEntry appends the fields fs as "identifier[pid] priority: message
+// KEY=value ...". Without header the identifier and pid are left out, for
+// sinks that record them on their own.
+func appendTextEntry(b []byte, fs []Field, header bool) []byte {
+	var msg, prio, ident []byte
+	rest := fs[:0:0]
+	for _, f := range fs {
+		switch f.Key {
+		case "MESSAGE":
+			msg = f.Value
+		case "PRIORITY":
+			prio = f.Value
+		case "SYSLOG_IDENTIFIER":
+			ident = f.Value
+		default:
+			rest = append(rest, f)
+		}
+	}
+	if header {
+		b = append(b, ident...)
+		b = append(b, '[')
+		b = strconv.AppendInt(b, int64(os.Getpid()), 10)
+		b = append(b, "] "...)
+	}
+	if n, err := strconv.Atoi(string(prio)); err == nil && n >= 0 && n < len(priorityNames) {
+		b = append(b, priorityNames[n]...)
+	} else {
+		b = append(b, prio...)
+	}
+	b = append(b, ": "...)
+	b = append(b, msg...)
+	for _, f := range rest {
+		b = append(b, ' ')
+		b = append(b, f.Key...)
+		b = append(b, '=')
+		b = appendTextValue(b, f.Value)
+	}
+	return b
+}
+
+// appendTextValue appends v, quoting it if it is empty or contains spaces,
+// quotes, control characters or invalid UTF-8.
+func appendTextValue(b, v []byte) []byte {
+	if len(v) == 0 || !utf8.Valid(v) {
+		return strconv.AppendQuote(b, string(v))
+	}
+	for _, c := range v {
+		if c <= ' ' || c == '"' || c == '=' || c == 0x7f {
+			return strconv.AppendQuote(b, string(v))
+		}
+	}
+	return append(b, v...)
+}
@@ -0,0 +1,26 @@
+//go:build dragonfly || freebsd || netbsd || openbsd
+
+package slogjournal
+
+import (
+	"io"
+	"net"
+	"os"
+	"runtime"
+)
+
+// newFallbackWriter returns a writer to the local syslogd listening on
+// /var/run/log. FreeBSD and NetBSD parse RFC 5424 messages, which keep the
+// fields as structured data; the others get RFC 3164 messages. If syslogd
+// is not reachable it writes structured lines to standard error instead.
+func newFallbackWriter(string) io.Writer {
+	conn, err := net.Dial("unixgram", "/var/run/log")
+	if err != nil {
+		return &textWriter{w: os.Stderr}
+	}
+	format := RFC3164
+	if runtime.GOOS == "freebsd" || runtime.GOOS == "netbsd" {
+		format = RFC5424
+	}
+	return newSyslogWriter(conn, format)
+}
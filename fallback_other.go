@@ -0,0 +1,14 @@
+//go:build !windows && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package slogjournal
+
+import (
+	"io"
+	"os"
+)
+
+// newFallbackWriter returns the writer used when there is no journal on
+// this platform: structured lines on standard error.
+func newFallbackWriter(string) io.Writer {
+	return &textWriter{w: os.Stderr}
+}
@@ -0,0 +1,30 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTextWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{Identifier: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	h.w = &textWriter{w: buf, now: func() time.Time { return now }}
+	r := slog.NewRecord(time.Time{}, slog.LevelWarn, "disk almost full", 0)
+	r.AddAttrs(slog.String("DEVICE", "/dev/sda"), slog.String("NOTE", "two words"))
+	if err := h.Handle(context.TODO(), r); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("2024-01-02T03:04:05.000Z app[%d] warning: disk almost full DEVICE=/dev/sda NOTE=\"two words\"\n", os.Getpid())
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
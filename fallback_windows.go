@@ -0,0 +1,61 @@
+//go:build windows
+
+package slogjournal
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventID is the event identifier of every record written to the Event Log.
+const eventID = 1
+
+// newFallbackWriter returns a writer to the Windows Event Log using source
+// as event source. If the Event Log cannot be opened it writes structured
+// lines to standard error instead.
+func newFallbackWriter(source string) io.Writer {
+	l, err := eventlog.Open(source)
+	if err != nil {
+		return &textWriter{w: os.Stderr}
+	}
+	return &eventLogWriter{log: l}
+}
+
+// eventLogWriter writes entries to the Windows Event Log. The priority
+// selects the event type; the message and fields make up the event text.
+type eventLogWriter struct {
+	log eventLogger
+}
+
+// eventLogger is the part of [eventlog.Log] used by eventLogWriter.
+type eventLogger interface {
+	Error(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Info(eid uint32, msg string) error
+}
+
+func (e *eventLogWriter) Write(p []byte) (int, error) {
+	fs := parseFields(p)
+	prio := logInfo
+	for _, f := range fs {
+		if f.Key == "PRIORITY" && len(f.Value) == 1 {
+			prio = Priority(f.Value[0] - '0')
+		}
+	}
+	msg := string(appendTextEntry(nil, fs, false))
+	var err error
+	switch {
+	case prio <= logErr:
+		err = e.log.Error(eventID, msg)
+	case prio == logWarning:
+		err = e.log.Warning(eventID, msg)
+	default:
+		err = e.log.Info(eventID, msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
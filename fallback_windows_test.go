@@ -0,0 +1,62 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eventRecorder is an eventLogger remembering every event.
+type eventRecorder struct {
+	events []string
+}
+
+func (r *eventRecorder) Error(eid uint32, msg string) error {
+	r.events = append(r.events, "error: "+msg)
+	return nil
+}
+
+func (r *eventRecorder) Warning(eid uint32, msg string) error {
+	r.events = append(r.events, "warning: "+msg)
+	return nil
+}
+
+func (r *eventRecorder) Info(eid uint32, msg string) error {
+	r.events = append(r.events, "info: "+msg)
+	return nil
+}
+
+func TestEventLogWriter(t *testing.T) {
+	rec := &eventRecorder{}
+	h, err := NewHandler(&Options{Level: slog.LevelDebug})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = &eventLogWriter{log: rec}
+	for _, l := range []slog.Level{LevelCritical, slog.LevelError, slog.LevelWarn, slog.LevelInfo, slog.LevelDebug} {
+		r := slog.NewRecord(time.Time{}, l, "disk almost full", 0)
+		r.AddAttrs(slog.String("DEVICE", "C:"))
+		if err := h.Handle(context.TODO(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"error", "error", "warning", "info", "info"}
+	if len(rec.events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(rec.events), len(want))
+	}
+	for i, e := range rec.events {
+		if !strings.HasPrefix(e, want[i]+": ") || !strings.Contains(e, "disk almost full") || !strings.Contains(e, "DEVICE=C:") {
+			t.Errorf("event %d: unexpected %q", i, e)
+		}
+	}
+}
+
+func TestFallbackWriter(t *testing.T) {
+	switch w := newFallbackWriter("slogjournal-test").(type) {
+	case *eventLogWriter, *textWriter:
+	default:
+		t.Errorf("unexpected fallback writer %T", w)
+	}
+}
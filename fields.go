@@ -0,0 +1,159 @@
+package slogjournal
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DuplicateKeyPolicy controls what happens when a record contains the same
+// field name more than once.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeyAllow sends every occurrence of a key. The journal accepts
+	// repeated fields, but many downstream consumers do not.
+	DuplicateKeyAllow DuplicateKeyPolicy = iota
+	// DuplicateKeyFirstWins keeps only the first occurrence of a key.
+	DuplicateKeyFirstWins
+	// DuplicateKeyLastWins keeps only the last occurrence of a key.
+	DuplicateKeyLastWins
+	// DuplicateKeyIndex keeps every occurrence but renames the second and
+	// later ones to KEY_1, KEY_2, and so on, skipping names that are
+	// already in the record.
+	DuplicateKeyIndex
+)
+
+// parseFields splits a buffer built by the handler back into its fields.
+// Such buffers are always well-formed.
+func parseFields(b []byte) []Field {
+	fs, _ := Decode(b)
+	return fs
+}
+
+// rewriteFields decodes the fields in b, applies the configured duplicate key
+// policy and ordering, and encodes them again. The first head bytes of b hold
+// the fields the handler emitted itself; they stay in front, and their length
+// in the result is returned.
+func (h *Handler) rewriteFields(b []byte, head int) ([]byte, int) {
+	fs := parseFields(b)
+	fs, n := applyDuplicateKeyPolicy(fs, len(parseFields(b[:head])), h.opts.DuplicateKeys)
+	if h.opts.SortFields {
+		sortFields(fs[:n])
+		sortFields(fs[n:])
+	}
+	out := make([]byte, 0, len(b))
+	for i, f := range fs {
+		if i == n {
+			head = len(out)
+		}
+		out = h.encodeKV(out, f.Key, f.Value)
+	}
+	if n == len(fs) {
+		head = len(out)
+	}
+	return out, head
+}
+
+// applyDuplicateKeyPolicy filters or renames repeated keys in fs according to
+// p. The first head fields are the handler's own; it returns how many of them
+// are kept, which are still the first fields of the result.
+func applyDuplicateKeyPolicy(fs []Field, head int, p DuplicateKeyPolicy) ([]Field, int) {
+	if p == DuplicateKeyAllow {
+		return fs, head
+	}
+	seen := make(map[string]int, len(fs))
+	switch p {
+	case DuplicateKeyFirstWins:
+		out, kept := fs[:0], 0
+		for i, f := range fs {
+			if seen[f.Key] > 0 {
+				continue
+			}
+			seen[f.Key]++
+			if i < head {
+				kept++
+			}
+			out = append(out, f)
+		}
+		return out, kept
+	case DuplicateKeyLastWins:
+		for _, f := range fs {
+			seen[f.Key]++
+		}
+		out, kept := fs[:0], 0
+		for i, f := range fs {
+			seen[f.Key]--
+			if seen[f.Key] > 0 {
+				continue
+			}
+			if i < head {
+				kept++
+			}
+			out = append(out, f)
+		}
+		return out, kept
+	case DuplicateKeyIndex:
+		// Keys already in the record, such as a literal KEY_1, are taken.
+		used := make(map[string]bool, len(fs))
+		for _, f := range fs {
+			used[f.Key] = true
+		}
+		for i, f := range fs {
+			n := seen[f.Key]
+			seen[f.Key]++
+			if n == 0 {
+				continue
+			}
+			k := f.Key + "_" + strconv.Itoa(n)
+			for used[k] {
+				n++
+				k = f.Key + "_" + strconv.Itoa(n)
+			}
+			seen[f.Key] = n + 1
+			used[k] = true
+			fs[i].Key = k
+		}
+	}
+	return fs, head
+}
+
+// builtinOrder lists the fields the handler emits itself, in the order they
+// are placed in front of user fields when sorting.
+var builtinOrder = map[string]int{
+	"MESSAGE":             1,
+	"PRIORITY":            2,
+	"LEVEL":               3,
+	"VERBOSITY":           4,
+	"CODE_FILE":           5,
+	"CODE_FUNC":           6,
+	"CODE_LINE":           7,
+	"SYSLOG_TIMESTAMP":    8,
+	"MONOTONIC_TIMESTAMP": 9,
+	"SYSLOG_IDENTIFIER":   10,
+	"NAME":                11,
+	"DOCUMENTATION":       12,
+	"TRACE_ID":            13,
+	"SPAN_ID":             14,
+	"TRACE_FLAGS":         15,
+	"REQUEST_ID":          16,
+}
+
+// sortFields orders fs with the builtin fields first, followed by all other
+// fields sorted by key. The sort is stable, so repeated keys keep their
+// relative order.
+func sortFields(fs []Field) {
+	slices.SortStableFunc(fs, func(a, b Field) int {
+		ra, rb := builtinOrder[a.Key], builtinOrder[b.Key]
+		switch {
+		case ra != 0 && rb != 0:
+			return cmp.Compare(ra, rb)
+		case ra != 0:
+			return -1
+		case rb != 0:
+			return 1
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package slogjournal
+
+import (
+	"errors"
+	"os"
+)
+
+// fileID fails, as journal streams do not exist on this platform.
+func fileID(*os.File) (string, error) {
+	return "", errors.ErrUnsupported
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileID returns the device and inode number of f in the format used by
+// $JOURNAL_STREAM.
+func fileID(f *os.File) (string, error) {
+	var st syscall.Stat_t
+	if err := syscall.Fstat(int(f.Fd()), &st); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d:%d", st.Dev, st.Ino), nil
+}
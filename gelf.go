@@ -0,0 +1,179 @@
+package slogjournal
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GELFOptions configure a [GELFConn].
+type GELFOptions struct {
+	// TLSConfig, if set, secures "tcp" connections with TLS.
+	TLSConfig *tls.Config
+
+	// Hostname is sent as host in every message. The default is
+	// os.Hostname.
+	Hostname string
+
+	// DialTimeout limits connecting and writing a message. The default is
+	// five seconds.
+	DialTimeout time.Duration
+
+	// ChunkSize is the largest datagram sent over "udp". Larger messages
+	// are split into chunks. The default is 1420 bytes, which fits the
+	// usual Ethernet MTU.
+	ChunkSize int
+}
+
+// GELFConn is an [io.Writer] that sends journal entries to [Graylog] or
+// another server accepting the Graylog Extended Log Format, for
+// environments where the journal is not the final destination. Pass it in
+// Options.Mirrors, which receive every entry in the native protocol; the
+// Writer of a [Route] receives lines of text and cannot be used.
+//
+// MESSAGE becomes short_message, PRIORITY the level and SYSLOG_TIMESTAMP
+// the timestamp. All other fields become additional fields named after the
+// journal field with an underscore prepended, such as _SYSLOG_IDENTIFIER.
+// If a field is repeated, the last value wins.
+//
+// Over "udp" messages are sent uncompressed and chunked if necessary; over
+// "tcp" they are terminated by a NUL byte. A broken connection is redialed
+// on the next Write. GELFConn is safe for concurrent use.
+//
+// [Graylog]: https://go2docs.graylog.org/current/getting_in_log_data/gelf.html
+type GELFConn struct {
+	remoteConn
+	hostname  string
+	chunkSize int
+}
+
+// maxChunks is the largest number of chunks a GELF message may be split
+// into.
+const maxChunks = 128
+
+// gelfChunkHeader is the length of the header of a GELF chunk: two magic
+// bytes, an eight byte message ID, the sequence number and count.
+const gelfChunkHeader = 12
+
+// DialGELF connects to the GELF server at addr. network is "udp" or "tcp",
+// or one of their variants such as "tcp6". If opts is nil, the default
+// options are used.
+func DialGELF(network, addr string, opts *GELFOptions) (*GELFConn, error) {
+	var o GELFOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Hostname == "" {
+		o.Hostname, _ = os.Hostname()
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = 5 * time.Second
+	}
+	if o.ChunkSize <= gelfChunkHeader {
+		o.ChunkSize = 1420
+	}
+	c := &GELFConn{
+		remoteConn: remoteConn{network: network, addr: addr, tlsConfig: o.TLSConfig, timeout: o.DialTimeout},
+		hostname:   o.Hostname,
+		chunkSize:  o.ChunkSize,
+	}
+	if err := c.dial(); err != nil {
+		return nil, fmt.Errorf("slogjournal: dial GELF: %w", err)
+	}
+	return c, nil
+}
+
+// Write sends the journal entry p as a single GELF message.
+func (c *GELFConn) Write(p []byte) (int, error) {
+	fs, err := Decode(p)
+	if err != nil {
+		return 0, err
+	}
+	msg, err := appendGELF(nil, fs, c.hostname, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if c.stream() {
+		err = c.write(append(msg, 0))
+	} else {
+		var chunks [][]byte
+		if chunks, err = gelfChunks(msg, c.chunkSize); err == nil {
+			err = c.write(chunks...)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection.
+func (c *GELFConn) Close() error {
+	return c.close()
+}
+
+// appendGELF appends the fields fs as a GELF 1.1 message.
+func appendGELF(b []byte, fs []Field, hostname string, t time.Time) ([]byte, error) {
+	m := map[string]any{
+		"version": "1.1",
+		"host":    hostname,
+		"level":   int(logInfo),
+	}
+	for _, f := range fs {
+		switch f.Key {
+		case "MESSAGE":
+			m["short_message"] = string(f.Value)
+		case "PRIORITY":
+			if n, err := strconv.Atoi(string(f.Value)); err == nil {
+				m["level"] = n
+			}
+		case "SYSLOG_TIMESTAMP":
+			if ts, ok := ParseSyslogTimestamp(string(f.Value)); ok {
+				t = ts
+			}
+		default:
+			m["_"+f.Key] = string(f.Value)
+		}
+	}
+	if s, _ := m["short_message"].(string); s == "" {
+		// short_message is required and must not be empty.
+		m["short_message"] = "-"
+	}
+	m["timestamp"] = json.Number(strconv.FormatFloat(float64(t.UnixMicro())/1e6, 'f', 6, 64))
+	js, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, js...), nil
+}
+
+// gelfChunks splits msg into datagrams of at most size bytes.
+func gelfChunks(msg []byte, size int) ([][]byte, error) {
+	if len(msg) <= size {
+		return [][]byte{msg}, nil
+	}
+	n := size - gelfChunkHeader
+	count := (len(msg) + n - 1) / n
+	if count > maxChunks {
+		return nil, errors.New("slogjournal: GELF message too large")
+	}
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	chunks := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		chunk := make([]byte, 0, size)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, msg[i*n:min(len(msg), (i+1)*n)]...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,87 @@
+package slogjournal
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestGELFConn(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+	c, err := DialGELF("udp", pc.LocalAddr().String(), &GELFOptions{Hostname: "host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	h, err := NewHandler(&Options{Identifier: "app", Mirrors: []io.Writer{c}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = io.Discard
+	before := time.Now().Truncate(time.Microsecond)
+	slog.New(h).Warn("disk almost full", "DEVICE", "/dev/sda")
+	after := time.Now()
+
+	buf := make([]byte, 2048)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(buf[:n], &m); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]any{
+		"version":            "1.1",
+		"host":               "host",
+		"short_message":      "disk almost full",
+		"level":              4.0,
+		"_SYSLOG_IDENTIFIER": "app",
+		"_DEVICE":            "/dev/sda",
+	} {
+		if m[k] != want {
+			t.Errorf("%s = %v, want %v", k, m[k], want)
+		}
+	}
+	// The timestamp keeps the record's sub-second precision.
+	ts, _ := m["timestamp"].(float64)
+	if tm := time.UnixMicro(int64(math.Round(ts * 1e6))); tm.Before(before) || tm.After(after) {
+		t.Errorf("timestamp %v not in [%v, %v]", tm, before, after)
+	}
+}
+
+func TestGELFChunks(t *testing.T) {
+	msg := bytes.Repeat([]byte("x"), 100)
+	chunks, err := gelfChunks(msg, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 4 {
+		t.Fatalf("got %d chunks, want 4", len(chunks))
+	}
+	var joined []byte
+	for i, c := range chunks {
+		if len(c) > 42 || c[0] != 0x1e || c[1] != 0x0f || c[10] != byte(i) || c[11] != 4 {
+			t.Errorf("malformed chunk %d: %x", i, c[:12])
+		}
+		if !bytes.Equal(c[2:10], chunks[0][2:10]) {
+			t.Errorf("chunk %d has a different message ID", i)
+		}
+		joined = append(joined, c[12:]...)
+	}
+	if !bytes.Equal(joined, msg) {
+		t.Error("chunks do not add up to the message")
+	}
+	if _, err := gelfChunks(bytes.Repeat([]byte("x"), 129*30), 42); err == nil {
+		t.Error("expected error for a message needing more than 128 chunks")
+	}
+}
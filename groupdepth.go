@@ -0,0 +1,86 @@
+package slogjournal
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+)
+
+// GroupOverflowPolicy controls how groups nested deeper than
+// Options.MaxGroupDepth are sent.
+type GroupOverflowPolicy int
+
+const (
+	// GroupOverflowJSON sends the first group beyond the limit as a single
+	// field holding its attributes, including nested groups, as a JSON
+	// object. Attributes added by separate calls, such as WithAttrs and the
+	// log call itself, end up in separate fields of the same name.
+	GroupOverflowJSON GroupOverflowPolicy = iota
+	// GroupOverflowHash replaces the names of the groups beyond the limit
+	// with a hash of their path, so that the fields keep their own names
+	// while distinct paths stay distinct.
+	GroupOverflowHash
+)
+
+// groupHashLen is the length of the hashed segment GroupOverflowHash adds
+// to field names.
+const groupHashLen = len("01234567_")
+
+// overflowPrefix returns prefix extended by the group name, which is nested
+// in depth groups, beyond Options.MaxGroupDepth with GroupOverflowHash. The
+// hash covers the names of all groups beyond the limit.
+func (h *Handler) overflowPrefix(prefix []byte, depth int, name string) []byte {
+	sum := fnv.New32a()
+	if depth > h.opts.MaxGroupDepth {
+		// Replace the hash of the enclosing groups, copying prefix since
+		// the caller's view of it must stay intact.
+		sum.Write(prefix[len(prefix)-groupHashLen:])
+		prefix = slices.Clip(prefix[:len(prefix)-groupHashLen])
+	}
+	sum.Write([]byte(name))
+	return fmt.Appendf(prefix, "%08X_", sum.Sum32())
+}
+
+// groupOverflow reports whether a group nested in depth groups exceeds
+// Options.MaxGroupDepth.
+func (h *Handler) groupOverflow(depth int) bool {
+	return h.opts.MaxGroupDepth > 0 && depth >= h.opts.MaxGroupDepth
+}
+
+// deepGroups reports whether the groups added with WithGroup exceed
+// Options.MaxGroupDepth with GroupOverflowJSON, so attributes must be
+// passed to appendDeepAttrs.
+func (h *Handler) deepGroups() bool {
+	return h.opts.GroupOverflow == GroupOverflowJSON && h.groupOverflow(len(h.groups)-1)
+}
+
+// appendGroupJSON appends the field k holding attrs, which are in the given
+// groups, as a JSON object to b.
+func (h *Handler) appendGroupJSON(b, k []byte, groups []string, attrs []slog.Attr) []byte {
+	js := []byte{'{'}
+	sep := ""
+	for _, a := range attrs {
+		var ok bool
+		if js, ok = h.appendJSONAttr(js, sep, groups, a); ok {
+			sep = ","
+		}
+	}
+	js = append(js, '}')
+	return h.appendAttrKV(b, k, slog.StringValue(string(js)))
+}
+
+// appendDeepAttrs appends attrs, added to a handler whose groups exceed
+// Options.MaxGroupDepth, as a JSON object nested in the groups beyond the
+// limit. See deepGroups.
+func (h *Handler) appendDeepAttrs(b, prefix []byte, attrs []slog.Attr) []byte {
+	if len(attrs) == 0 {
+		return b
+	}
+	max := h.opts.MaxGroupDepth
+	names := h.groups[max:]
+	for i := len(names) - 1; i > 0; i-- {
+		attrs = []slog.Attr{{Key: names[i], Value: slog.GroupValue(attrs...)}}
+	}
+	return h.appendGroupJSON(b, append(prefix, names[0]...), h.groups[:max+1], attrs)
+}
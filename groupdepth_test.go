@@ -0,0 +1,71 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMaxGroupDepthJSON(t *testing.T) {
+	ew := &entryWriter{}
+	h, err := NewHandler(&Options{Level: slog.LevelInfo, MaxGroupDepth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = ew
+	l := slog.New(h).WithGroup("A")
+	l.Info("attr groups", slog.Group("B", slog.Int("X", 1), slog.Group("C", slog.Group("D", slog.String("Y", "z")))))
+	l.WithGroup("B").WithGroup("C").With("X", 1).WithGroup("D").Info("handler groups", "Y", "z")
+
+	want := [][]string{
+		{"A_B_X=1", `A_B_C={"D":{"Y":"z"}}`},
+		// Attributes added by With and the log call are sent separately.
+		{`A_B_C={"X":1}`, `A_B_C={"D":{"Y":"z"}}`},
+	}
+	for i, w := range want {
+		var got []string
+		for _, f := range parseFields(ew.entries[i]) {
+			if strings.HasPrefix(f.Key, "A_") {
+				got = append(got, f.Key+"="+string(f.Value))
+			}
+		}
+		if !slices.Equal(got, w) {
+			t.Errorf("entry %d: got %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestMaxGroupDepthHash(t *testing.T) {
+	ew := &entryWriter{}
+	h, err := NewHandler(&Options{Level: slog.LevelInfo, MaxGroupDepth: 1, GroupOverflow: GroupOverflowHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = ew
+	l := slog.New(h)
+	l.Info("attr groups", slog.Group("A", slog.Group("B", slog.Group("C", slog.Int("X", 1)))))
+	l.WithGroup("A").WithGroup("B").WithGroup("C").Info("handler groups", "X", 1)
+	l.Info("other path", slog.Group("A", slog.Group("B", slog.Group("D", slog.Int("X", 1)))))
+
+	var keys []string
+	for i := range ew.entries {
+		for _, f := range parseFields(ew.entries[i]) {
+			if strings.HasSuffix(f.Key, "_X") {
+				keys = append(keys, f.Key)
+			}
+		}
+	}
+	if len(keys) != 3 {
+		t.Fatalf("got keys %q, want 3", keys)
+	}
+	if !strings.HasPrefix(keys[0], "A_") || len(keys[0]) != len("A_01234567_X") {
+		t.Errorf("key %q does not hash the groups beyond A", keys[0])
+	}
+	if keys[0] != keys[1] {
+		t.Errorf("attribute groups gave %q, handler groups %q", keys[0], keys[1])
+	}
+	if keys[0] == keys[2] {
+		t.Errorf("distinct paths share key %q", keys[0])
+	}
+}
@@ -0,0 +1,64 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// HeartbeatMessageID is the MESSAGE_ID of the records logged by
+// [StartHeartbeat].
+const HeartbeatMessageID = "3b9f3c0e6a0f4b3a9c1d2e7f5a8b6c4d"
+
+// StartHeartbeat logs a heartbeat record with runtime statistics to logger
+// at level every interval, giving deployments that only collect the
+// journal basic liveness telemetry. The records carry the fields
+// GOROUTINES, HEAP_ALLOC_BYTES, HEAP_OBJECTS, GC_COUNT, GC_PAUSE_TOTAL and
+// GC_PAUSE_LAST, with the pauses in microseconds, UPTIME, and MESSAGE_ID
+// HeartbeatMessageID so they can be selected with journalctl. The returned
+// function stops the heartbeat.
+func StartHeartbeat(logger *slog.Logger, interval time.Duration, level slog.Level) (stop func()) {
+	start := time.Now()
+	t := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				logHeartbeat(logger, level, start)
+			}
+		}
+	}()
+	return sync.OnceFunc(func() {
+		t.Stop()
+		close(done)
+	})
+}
+
+// logHeartbeat logs a single heartbeat record.
+func logHeartbeat(logger *slog.Logger, level slog.Level, start time.Time) {
+	ctx := context.Background()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	var last time.Duration
+	if m.NumGC > 0 {
+		last = time.Duration(m.PauseNs[(m.NumGC+255)%256])
+	}
+	logger.LogAttrs(ctx, level, "heartbeat",
+		slog.String(MessageIDKey, HeartbeatMessageID),
+		slog.Int("GOROUTINES", runtime.NumGoroutine()),
+		slog.Uint64("HEAP_ALLOC_BYTES", m.HeapAlloc),
+		slog.Uint64("HEAP_OBJECTS", m.HeapObjects),
+		slog.Uint64("GC_COUNT", uint64(m.NumGC)),
+		slog.Duration("GC_PAUSE_TOTAL", time.Duration(m.PauseTotalNs)),
+		slog.Duration("GC_PAUSE_LAST", last),
+		slog.Duration("UPTIME", time.Since(start)),
+	)
+}
@@ -0,0 +1,46 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	w := &entryWriter{}
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = w
+	runtime.GC()
+	stop := StartHeartbeat(slog.New(h), 10*time.Millisecond, slog.LevelInfo)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(w.messages()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+	stop()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.entries) < 2 {
+		t.Fatalf("expected at least 2 heartbeats, got %d", len(w.entries))
+	}
+	m := map[string]string{}
+	for _, f := range parseFields(w.entries[0]) {
+		m[f.Key] = string(f.Value)
+	}
+	if m["MESSAGE"] != "heartbeat" || m[MessageIDKey] != HeartbeatMessageID {
+		t.Errorf("unexpected entry %v", m)
+	}
+	for _, k := range []string{"GOROUTINES", "HEAP_ALLOC_BYTES", "HEAP_OBJECTS", "GC_COUNT", "GC_PAUSE_TOTAL", "GC_PAUSE_LAST", "UPTIME"} {
+		if m[k] == "" {
+			t.Errorf("missing field %s in %v", k, m)
+		}
+	}
+	if m["GC_COUNT"] == "0" {
+		t.Errorf("expected a garbage collection to be counted")
+	}
+}
@@ -0,0 +1,124 @@
+// Package httpjournal provides net/http middleware that logs every request
+// as a journal entry, so the journal can serve as an access log that is
+// filtered by field:
+//
+//	journalctl STATUS_CODE=404 REQUEST_METHOD=POST
+package httpjournal
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Fields of the access log entries.
+const (
+	MethodKey     = "REQUEST_METHOD"
+	PathKey       = "REQUEST_PATH"
+	StatusKey     = "STATUS_CODE"
+	DurationKey   = "DURATION_USEC"
+	RemoteAddrKey = "REMOTE_ADDR"
+	BytesKey      = "RESPONSE_BYTES"
+)
+
+// Handler returns an [http.Handler] that serves requests with next and logs
+// one record per request to logger, with the fields REQUEST_METHOD,
+// REQUEST_PATH, STATUS_CODE, DURATION_USEC, REMOTE_ADDR and RESPONSE_BYTES.
+// Requests answered with a 5xx status are logged at slog.LevelError, all
+// others at slog.LevelInfo. If next panics, the request is logged with
+// status 500 and the panic continues.
+func Handler(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			if p := recover(); p != nil {
+				rw.status = http.StatusInternalServerError
+				logRequest(r.Context(), logger, r, rw, time.Since(start))
+				panic(p)
+			}
+			logRequest(r.Context(), logger, r, rw, time.Since(start))
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Middleware returns Handler as middleware for routers that chain
+// func(http.Handler) http.Handler.
+func Middleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return Handler(logger, next)
+	}
+}
+
+func logRequest(ctx context.Context, logger *slog.Logger, r *http.Request, rw *responseWriter, d time.Duration) {
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	level := slog.LevelInfo
+	if status >= 500 {
+		level = slog.LevelError
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	logger.LogAttrs(ctx, level, r.Method+" "+r.URL.Path+" "+strconv.Itoa(status),
+		slog.String(MethodKey, r.Method),
+		slog.String(PathKey, r.URL.Path),
+		slog.Int(StatusKey, status),
+		slog.Int64(DurationKey, d.Microseconds()),
+		slog.String(RemoteAddrKey, r.RemoteAddr),
+		slog.Int64(BytesKey, rw.bytes),
+	)
+}
+
+// responseWriter records the status code and size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements [http.Flusher] if the underlying writer supports it.
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements [http.Hijacker], for websocket upgrades and the like.
+// It returns an error wrapping [http.ErrNotSupported] if the underlying
+// writer does not support it.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap lets [http.ResponseController] reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,194 @@
+package httpjournal_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	slogjournal "github.com/systemd/slog-journal"
+	"github.com/systemd/slog-journal/httpjournal"
+	"github.com/systemd/slog-journal/journaltest"
+)
+
+func TestHandler(t *testing.T) {
+	srv := journaltest.NewServer(t)
+	h, err := slogjournal.NewHandler(&slogjournal.Options{Addr: srv.Addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusServiceUnavailable)
+	})
+	handler := httpjournal.Middleware(slog.New(h))(mux)
+
+	for _, path := range []string{"/hello", "/fail", "/missing"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	srv.WaitEntries(t, 3)
+	e := srv.AssertLogged(t, journaltest.Fields{
+		"MESSAGE":        "GET /hello 200",
+		"PRIORITY":       "6",
+		"REQUEST_METHOD": "GET",
+		"REQUEST_PATH":   "/hello",
+		"STATUS_CODE":    "200",
+		"REMOTE_ADDR":    "192.0.2.1:1234",
+		"RESPONSE_BYTES": "5",
+	})
+	journaltest.AssertHasFields(t, e, "DURATION_USEC")
+	srv.AssertLogged(t, journaltest.Fields{"REQUEST_PATH": "/fail", "STATUS_CODE": "503", "PRIORITY": "3"})
+	srv.AssertLogged(t, journaltest.Fields{"REQUEST_PATH": "/missing", "STATUS_CODE": "404"})
+}
+
+func TestHandlerPanic(t *testing.T) {
+	srv := journaltest.NewServer(t)
+	h, err := slogjournal.NewHandler(&slogjournal.Options{Addr: srv.Addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := httpjournal.Handler(slog.New(h), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("expected the panic to continue, got %v", p)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	}()
+	srv.WaitEntries(t, 1)
+	srv.AssertLogged(t, journaltest.Fields{"REQUEST_PATH": "/panic", "STATUS_CODE": "500", "PRIORITY": "3"})
+}
+
+func TestHandlerInterfaces(t *testing.T) {
+	srv := journaltest.NewServer(t)
+	h, err := slogjournal.NewHandler(&slogjournal.Options{Addr: srv.Addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := httpjournal.Handler(slog.New(h), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stream" {
+			w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+		buf.Flush()
+	}))
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	for _, path := range []string{"/stream", "/upgrade"} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	srv.WaitEntries(t, 2)
+	srv.AssertLogged(t, journaltest.Fields{"REQUEST_PATH": "/stream", "STATUS_CODE": "200", "RESPONSE_BYTES": "5"})
+	srv.AssertLogged(t, journaltest.Fields{"REQUEST_PATH": "/upgrade", "STATUS_CODE": "101"})
+}
+
+func TestRecorder(t *testing.T) {
+	h, err := slogjournal.NewHandler(&slogjournal.Options{
+		Level:         slog.LevelDebug,
+		RecentEntries: 3,
+		Identifier:    "svc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetLogTarget(slogjournal.LogTargetNull)
+	rec := httpjournal.NewRecorder(h)
+	l := slog.New(h)
+	l.Info("dropped")
+	l.Debug("debug", "K", 1)
+	l.Warn("warning", "K", "a b")
+	l.Info("info")
+
+	if got := len(rec.Entries()); got != 3 {
+		t.Fatalf("got %d entries, want 3", got)
+	}
+	get := func(query string) string {
+		w := httptest.NewRecorder()
+		rec.ServeHTTP(w, httptest.NewRequest("GET", "/debug/journal?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", query, w.Code)
+		}
+		return w.Body.String()
+	}
+	body := get("")
+	if strings.Contains(body, "dropped") {
+		t.Errorf("oldest entry not evicted:\n%s", body)
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], " debug   svc: debug") || !strings.Contains(lines[1], " warning svc: warning") || !strings.Contains(lines[1], `K="a b"`) {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+	if body := get("priority=4"); strings.Count(body, "\n") != 1 || !strings.Contains(body, "warning") {
+		t.Errorf("priority=4:\n%s", body)
+	}
+	if body := get("n=1"); strings.Count(body, "\n") != 1 || !strings.Contains(body, "info") {
+		t.Errorf("n=1:\n%s", body)
+	}
+	var objs []map[string]string
+	if err := json.Unmarshal([]byte(get("format=json")), &objs); err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 3 || objs[1]["MESSAGE"] != "warning" || objs[1]["K"] != "a b" {
+		t.Errorf("format=json: %v", objs)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	srv := journaltest.NewServer(t)
+	h, err := slogjournal.NewHandler(&slogjournal.Options{Addr: srv.Addr, Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(h)
+	mux := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "handling")
+	})
+	handler := httpjournal.RequestID(httpjournal.Handler(logger, mux))
+
+	req := httptest.NewRequest("GET", "/given", nil)
+	req.Header.Set(httpjournal.RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if got := w.Header().Get(httpjournal.RequestIDHeader); got != "abc-123" {
+		t.Errorf("response header %q, want abc-123", got)
+	}
+
+	req = httptest.NewRequest("GET", "/generated", nil)
+	req.Header.Set(httpjournal.RequestIDHeader, "bad\nid")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	id := w.Header().Get(httpjournal.RequestIDHeader)
+	if len(id) != 32 {
+		t.Fatalf("generated request ID %q", id)
+	}
+
+	srv.WaitEntries(t, 4)
+	srv.AssertLogged(t, journaltest.Fields{"MESSAGE": "handling", "REQUEST_ID": "abc-123"})
+	srv.AssertLogged(t, journaltest.Fields{"REQUEST_PATH": "/given", "REQUEST_ID": "abc-123"})
+	srv.AssertLogged(t, journaltest.Fields{"MESSAGE": "handling", "REQUEST_ID": id})
+	srv.AssertLogged(t, journaltest.Fields{"REQUEST_PATH": "/generated", "REQUEST_ID": id})
+}
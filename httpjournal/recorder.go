@@ -0,0 +1,136 @@
+package httpjournal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// Recorder serves the most recent journal entries of a handler over HTTP,
+// so operators can look at a service's logs when they cannot access its
+// journal. The entries are those kept by the handler's Options.RecentEntries,
+// see [slogjournal.Handler.Recent]. Register it like net/http/pprof:
+//
+//	h, err := slogjournal.NewHandler(&slogjournal.Options{
+//		RecentEntries: 1000,
+//	})
+//	...
+//	http.Handle("/debug/journal", httpjournal.NewRecorder(h))
+//
+// The handler serves one line per entry, oldest first, holding the
+// timestamp, the priority name, the identifier, the message and the other
+// fields as KEY=value pairs. The query parameter n limits the response to
+// the last n entries, priority to entries with at most the given priority,
+// like journalctl -p, and format=json selects a JSON array of objects
+// mapping field names to values instead. Like pprof, the endpoint exposes
+// internal data and should not be reachable by untrusted clients.
+type Recorder struct {
+	h *slogjournal.Handler
+}
+
+// NewRecorder returns a Recorder serving the recent entries of h. It serves
+// no entries unless h was created with Options.RecentEntries set.
+func NewRecorder(h *slogjournal.Handler) *Recorder {
+	return &Recorder{h: h}
+}
+
+// Entries returns the recent entries of the handler, decoded and oldest
+// first.
+func (rec *Recorder) Entries() [][]slogjournal.Field {
+	recent := rec.h.Recent()
+	out := make([][]slogjournal.Field, 0, len(recent))
+	for _, b := range recent {
+		// The handler only keeps well-formed entries.
+		if fs, err := slogjournal.Decode(b); err == nil {
+			out = append(out, fs)
+		}
+	}
+	return out
+}
+
+// ServeHTTP serves the recorded entries, see Recorder.
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	maxPrio := 7
+	if s := q.Get("priority"); s != "" {
+		p, err := strconv.Atoi(s)
+		if err != nil || p < 0 || p > 7 {
+			http.Error(w, "invalid priority "+strconv.Quote(s), http.StatusBadRequest)
+			return
+		}
+		maxPrio = p
+	}
+	var entries [][]slogjournal.Field
+	for _, fs := range rec.Entries() {
+		if p, err := strconv.Atoi(value(fs, "PRIORITY")); err != nil || p <= maxPrio {
+			entries = append(entries, fs)
+		}
+	}
+	if s := q.Get("n"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid n "+strconv.Quote(s), http.StatusBadRequest)
+			return
+		}
+		entries = entries[max(len(entries)-n, 0):]
+	}
+
+	if q.Get("format") == "json" {
+		objs := make([]map[string]string, len(entries))
+		for i, fs := range entries {
+			objs[i] = make(map[string]string, len(fs))
+			for _, f := range fs {
+				objs[i][f.Key] = string(f.Value)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(objs)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	var b strings.Builder
+	for _, fs := range entries {
+		b.Reset()
+		fmt.Fprintf(&b, "%s %-7s %s: %s", value(fs, "SYSLOG_TIMESTAMP"),
+			priorityName(value(fs, "PRIORITY")), value(fs, "SYSLOG_IDENTIFIER"), value(fs, "MESSAGE"))
+		for _, f := range fs {
+			switch f.Key {
+			case "SYSLOG_TIMESTAMP", "SYSLOG_IDENTIFIER", "PRIORITY", "MESSAGE":
+				continue
+			}
+			v := string(f.Value)
+			if strings.ContainsAny(v, " \"\n\t") || v == "" {
+				v = strconv.Quote(v)
+			}
+			fmt.Fprintf(&b, " %s=%s", f.Key, v)
+		}
+		b.WriteByte('\n')
+		_, _ = w.Write([]byte(b.String()))
+	}
+}
+
+// value returns the value of the last field named key in fs.
+func value(fs []slogjournal.Field, key string) string {
+	for i := len(fs) - 1; i >= 0; i-- {
+		if fs[i].Key == key {
+			return string(fs[i].Value)
+		}
+	}
+	return ""
+}
+
+// priorityNames are the syslog names of the journal priorities.
+var priorityNames = [...]string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// priorityName returns the syslog name of the priority s, or s itself if it
+// is not a valid priority.
+func priorityName(s string) string {
+	if p, err := strconv.Atoi(s); err == nil && p >= 0 && p < len(priorityNames) {
+		return priorityNames[p]
+	}
+	return s
+}
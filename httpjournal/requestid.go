@@ -0,0 +1,61 @@
+package httpjournal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// RequestIDHeader is the header RequestID takes request IDs from and
+// returns them in.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest request ID taken from a request.
+const maxRequestIDLength = 128
+
+// RequestID returns an [http.Handler] that serves requests with next in a
+// context carrying a request ID, see [slogjournal.ContextWithRequestID].
+// The ID is taken from the X-Request-ID header of the request, if it is
+// set by a proxy or client, or generated, and returned in the X-Request-ID
+// header of the response. Every record logged with the request's context
+// then has a REQUEST_ID field:
+//
+//	journalctl REQUEST_ID=4bf92f3577b34da6a3ce929d0e0e4736
+//
+// To include the access log entry of Handler, wrap it:
+//
+//	http.ListenAndServe(addr, httpjournal.RequestID(httpjournal.Handler(logger, mux)))
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(slogjournal.ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID reports whether id is short and consists of printable
+// ASCII characters only, so it cannot be used to inject fields or flood
+// the journal.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 128 random bits in hex.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
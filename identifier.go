@@ -0,0 +1,25 @@
+package slogjournal
+
+import "log/slog"
+
+// IdentifierKey is the key of the attribute that overrides the
+// SYSLOG_IDENTIFIER field. Like [NameKey], it only has this effect on a
+// string attribute outside of any group: passed to a single log call it
+// applies to that record only, which proxies and multi-tenant daemons need
+// when relaying on behalf of other components; passed to
+// [slog.Logger.With] it is equivalent to [Handler.WithIdentifier].
+const IdentifierKey = "SYSLOG_IDENTIFIER"
+
+// Identifier returns an attribute that sets the SYSLOG_IDENTIFIER of a
+// record, see [IdentifierKey]:
+//
+//	logger.Info("job done", slogjournal.Identifier("worker-3"))
+func Identifier(identifier string) slog.Attr {
+	return slog.String(IdentifierKey, identifier)
+}
+
+// isIdentifierAttr reports whether a sets the identifier when added to a
+// handler with the given groups.
+func isIdentifierAttr(groups []string, a slog.Attr) bool {
+	return len(groups) == 0 && a.Key == IdentifierKey && a.Value.Kind() == slog.KindString
+}
@@ -0,0 +1,84 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestIdentifierAttr(t *testing.T) {
+	buf := new(entryWriter)
+	h, err := NewHandler(&Options{Identifier: "proxy", Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	logger := slog.New(h)
+	logger.Info("relayed", Identifier("worker-3"))
+	logger.Info("own")
+	logger.With(Identifier("worker-4")).Info("with")
+	logger.WithGroup("G").Info("grouped", Identifier("ignored"))
+
+	for i, want := range []map[string]string{
+		{"SYSLOG_IDENTIFIER": "worker-3"},
+		{"SYSLOG_IDENTIFIER": "proxy"},
+		{"SYSLOG_IDENTIFIER": "worker-4"},
+		{"SYSLOG_IDENTIFIER": "proxy", "G_SYSLOG_IDENTIFIER": "ignored"},
+	} {
+		fs := parseFields(buf.entries[i])
+		var idents int
+		for _, f := range fs {
+			if f.Key == IdentifierKey {
+				idents++
+			}
+		}
+		if idents != 1 {
+			t.Errorf("entry %d: got %d SYSLOG_IDENTIFIER fields, want 1", i, idents)
+		}
+		kv, err := deserializeKeyValue(bytes.NewReader(buf.entries[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range want {
+			if kv[k] != v {
+				t.Errorf("entry %d: %s = %q, want %q", i, k, kv[k], v)
+			}
+		}
+	}
+}
+
+func TestIdentifierAttrDeepGroups(t *testing.T) {
+	buf := new(entryWriter)
+	h, err := NewHandler(&Options{Identifier: "proxy", Level: slog.LevelInfo, MaxGroupDepth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	logger := slog.New(h)
+	logger.Info("top", Identifier("worker-3"), slog.Group("A", slog.Group("B", slog.Int("X", 1))))
+	logger.WithGroup("A").WithGroup("B").Info("deep", Identifier("ignored"))
+
+	for i, want := range []map[string]string{
+		{"SYSLOG_IDENTIFIER": "worker-3", "A_B": `{"X":1}`},
+		{"SYSLOG_IDENTIFIER": "proxy", "A_B": `{"SYSLOG_IDENTIFIER":"ignored"}`},
+	} {
+		var idents int
+		for _, f := range parseFields(buf.entries[i]) {
+			if f.Key == IdentifierKey {
+				idents++
+			}
+		}
+		if idents != 1 {
+			t.Errorf("entry %d: got %d SYSLOG_IDENTIFIER fields, want 1", i, idents)
+		}
+		kv, err := deserializeKeyValue(bytes.NewReader(buf.entries[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range want {
+			if kv[k] != v {
+				t.Errorf("entry %d: %s = %q, want %q", i, k, kv[k], v)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"os"
+)
+
+// Install sets [slog.Default] to a journal handler configured with opts when
+// the program's standard error is connected to the journal, as is the case
+// for services started by systemd, and to a [slog.TextHandler] writing to
+// standard error otherwise. The text handler honours opts.Level (defaulting
+// to a [LevelVar]) and opts.ReplaceAttr, and prints this package's levels by
+// name.
+//
+// The returned function restores the previous default logger.
+func Install(opts *Options) (cleanup func(), err error) {
+	var h slog.Handler
+	if StderrIsJournal() {
+		if h, err = NewHandler(opts); err != nil {
+			return nil, err
+		}
+	} else {
+		h = newConsoleHandler(opts)
+	}
+	prev := slog.Default()
+	slog.SetDefault(slog.New(h))
+	return func() { slog.SetDefault(prev) }, nil
+}
+
+// StderrIsJournal reports whether standard error is connected to the journal,
+// by comparing it with the device and inode recorded in $JOURNAL_STREAM.
+func StderrIsJournal() bool {
+	stream := os.Getenv("JOURNAL_STREAM")
+	if stream == "" {
+		return false
+	}
+	id, err := fileID(os.Stderr)
+	return err == nil && stream == id
+}
+
+// newConsoleHandler returns the handler Install uses outside of systemd.
+func newConsoleHandler(opts *Options) slog.Handler {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == nil {
+		o.Level = NewLevelVar()
+	}
+	replace := o.ReplaceAttr
+	return slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: o.Level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			a = ReplaceLevelName(groups, a)
+			if replace != nil {
+				a = replace(groups, a)
+			}
+			return a
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package slogjournal
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestInstall(t *testing.T) {
+	prev := slog.Default()
+
+	t.Setenv("JOURNAL_STREAM", "")
+	cleanup, err := Install(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := slog.Default().Handler().(*slog.TextHandler); !ok {
+		t.Errorf("expected a text handler outside of systemd, got %T", slog.Default().Handler())
+	}
+	cleanup()
+	if slog.Default() != prev {
+		t.Error("expected cleanup to restore the previous default logger")
+	}
+
+	id, err := fileID(os.Stderr)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("journal streams are not supported on this platform")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JOURNAL_STREAM", id)
+	cleanup, err = Install(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if _, ok := slog.Default().Handler().(*Handler); !ok {
+		t.Errorf("expected a journal handler under systemd, got %T", slog.Default().Handler())
+	}
+}
@@ -5,16 +5,21 @@ package slogjournal
 import (
 	"bytes"
 	"context"
-	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
-	"log/syslog"
+	"maps"
 	"os"
 	"path"
 	"runtime"
 	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 // Names of levels corresponding to syslog.Priority values.
@@ -27,6 +32,9 @@ const (
 
 // LevelVar is similar to [slog.LevelVar] but also implements the service side of [RestartMode=debug].
 // It looks if the environment variable DEBUG_INVOCATION is set and if so, sets the level to slog.LevelDebug.
+// Otherwise, like native systemd daemons, it honours a level set through the SYSTEMD_LOG_LEVEL
+// environment variable, which takes any value accepted by [ParseLevel].
+// The environment is consulted once, by [NewLevelVar] or on first use, and again on [LevelVar.Reload].
 // The zero value of LevelVar is equivalent to slog.LevelInfo.
 // In the future, we might extend the behaviour of LevelVar to implement [org.freedesktop.LogControl1].
 //
@@ -34,39 +42,122 @@ const (
 // [org.freedesktop.LogControl1]: https://www.freedesktop.org/software/systemd/man/latest/org.freedesktop.LogControl1.html
 type LevelVar struct {
 	slog.LevelVar
+	once sync.Once
+	// env is the level last loaded from the environment.
+	env atomic.Int64
+}
+
+// NewLevelVar returns a LevelVar initialized from the environment.
+func NewLevelVar() *LevelVar {
+	v := &LevelVar{}
+	v.once.Do(v.load)
+	return v
 }
 
 // Return v's level.
-// When invoked for the first time, checks if the environment variable DEBUG_INVOCATION is set and if so, sets the level to slog.LevelDebug before returning it.
+// If v has not been initialized from the environment yet, this happens first.
 func (v *LevelVar) Level() slog.Level {
-	sync.OnceFunc(func() {
-		if os.Getenv("DEBUG_INVOCATION") != "" {
-			v.Set(slog.LevelDebug)
-		}
-	})()
+	v.once.Do(v.load)
 	return v.LevelVar.Level()
 }
 
-func levelToPriority(l slog.Level) syslog.Priority {
+// Set sets v's level to l, overriding the level requested by the environment
+// until the next call to Reset or Reload.
+func (v *LevelVar) Set(l slog.Level) {
+	v.once.Do(v.load)
+	v.LevelVar.Set(l)
+}
+
+// Reload reads DEBUG_INVOCATION and SYSTEMD_LOG_LEVEL again and sets v's level
+// accordingly, or to slog.LevelInfo if neither requests a level.
+func (v *LevelVar) Reload() {
+	v.once.Do(func() {})
+	v.load()
+}
+
+// Reset discards any level set with Set and restores the level last loaded
+// from the environment.
+func (v *LevelVar) Reset() {
+	v.once.Do(v.load)
+	v.LevelVar.Set(slog.Level(v.env.Load()))
+}
+
+func (v *LevelVar) load() {
+	l := slog.LevelInfo
+	if el, ok := levelFromEnv(); ok {
+		l = el
+	}
+	v.env.Store(int64(l))
+	v.LevelVar.Set(l)
+}
+
+// levelFromEnv returns the level requested through the environment, if any.
+// DEBUG_INVOCATION takes precedence over SYSTEMD_LOG_LEVEL.
+func levelFromEnv() (slog.Level, bool) {
+	if os.Getenv("DEBUG_INVOCATION") != "" {
+		return slog.LevelDebug, true
+	}
+	if s := os.Getenv("SYSTEMD_LOG_LEVEL"); s != "" {
+		if l, err := ParseLevel(s); err == nil {
+			return l, true
+		}
+	}
+	return 0, false
+}
+
+// InvalidUTF8Policy controls how field values that are not valid UTF-8 are
+// handled.
+type InvalidUTF8Policy int
+
+const (
+	// InvalidUTF8Pass sends values unchanged.
+	InvalidUTF8Pass InvalidUTF8Policy = iota
+	// InvalidUTF8Replace replaces every invalid sequence with U+FFFD.
+	InvalidUTF8Replace
+	// InvalidUTF8Binary keeps the value unchanged but always sends it in the
+	// length-prefixed binary form, which journal consumers treat as binary
+	// data rather than text.
+	InvalidUTF8Binary
+)
+
+// levelName returns the name of l, using the names of the extra levels
+// defined by this package where they apply.
+func levelName(l slog.Level) string {
 	switch l {
-	case slog.LevelDebug:
-		return syslog.LOG_DEBUG
-	case slog.LevelInfo:
-		return syslog.LOG_INFO
 	case LevelNotice:
-		return syslog.LOG_NOTICE
-	case slog.LevelWarn:
-		return syslog.LOG_WARNING
-	case slog.LevelError:
-		return syslog.LOG_ERR
+		return "NOTICE"
 	case LevelCritical:
-		return syslog.LOG_CRIT
+		return "CRITICAL"
 	case LevelAlert:
-		return syslog.LOG_ALERT
+		return "ALERT"
 	case LevelEmergency:
-		return syslog.LOG_EMERG
+		return "EMERGENCY"
 	default:
-		return syslog.LOG_INFO
+		return l.String()
+	}
+}
+
+// LevelToPriority returns the journal priority for records at level l. Levels
+// between the named ones get the priority of the closest named level below
+// them, so slog.LevelWarn+1 maps to LOG_WARNING.
+func LevelToPriority(l slog.Level) Priority {
+	switch {
+	case l >= LevelEmergency:
+		return logEmerg
+	case l >= LevelAlert:
+		return logAlert
+	case l >= LevelCritical:
+		return logCrit
+	case l >= slog.LevelError:
+		return logErr
+	case l >= slog.LevelWarn:
+		return logWarning
+	case l >= LevelNotice:
+		return logNotice
+	case l >= slog.LevelInfo:
+		return logInfo
+	default:
+		return logDebug
 	}
 }
 
@@ -74,6 +165,151 @@ func levelToPriority(l slog.Level) syslog.Priority {
 type Options struct {
 	Level slog.Leveler
 
+	// Namespace selects the [journal namespace] to log to. The default
+	// namespace is used if empty.
+	//
+	// [journal namespace]: https://www.freedesktop.org/software/systemd/man/latest/systemd-journald.service.html#Journal%20Namespaces
+	Namespace string
+
+	// ExtraNamespaces are further journal namespaces every entry is sent
+	// to, besides the one selected by Namespace or Addr. Entries are encoded
+	// once and sent to each journal in turn, for setups that require
+	// duplicated retention, such as a separate audit namespace. The empty
+	// string denotes the default namespace.
+	ExtraNamespaces []string
+
+	// Routes send records to different destinations depending on their
+	// level, for example DEBUG records to a file, INFO and above to the
+	// journal and CRIT and above also to the kernel log. If set, every record
+	// is sent to each route whose level range contains it, and records
+	// matching no route are discarded. Routes are in effect while the log
+	// target is LogTargetJournal; switching to another target with
+	// SetLogTarget sends all records there instead.
+	Routes []Route
+
+	// RateLimitInterval and RateLimitBurst throttle records like journald's
+	// RateLimitIntervalSec= and RateLimitBurst= settings, but separately for
+	// every MESSAGE_ID, or for every message for records without one. Once
+	// RateLimitBurst records of a kind were logged within RateLimitInterval,
+	// further ones are suppressed until the interval is over. When it is, a
+	// record with a SUPPRESSED field counting them, and a
+	// SUPPRESSED_MESSAGE_ID field if they had one, is logged at
+	// slog.LevelWarn. This way one pathological event cannot drown out the
+	// rest. Suppressed records are counted in Stats.Dropped. Rate limiting
+	// is disabled if either is zero.
+	RateLimitInterval time.Duration
+	RateLimitBurst    int
+
+	// MessageTemplates treats record messages as templates: every {KEY} in
+	// a message is replaced with the value of the attribute with key KEY,
+	// or of the field named KEY added with WithAttrs. The message
+	// "user {USER_ID} logged in" with the attribute USER_ID=42 is sent as
+	// "user 42 logged in", while USER_ID is still sent as a field of its
+	// own. Placeholders without a matching attribute are kept as is.
+	MessageTemplates bool
+
+	// StatusLevel, if set, makes the message of every record at or above
+	// this level the service's status text, by sending STATUS= to the
+	// service manager (see [Notify]), so systemctl status shows the latest
+	// significant log line. Messages are put on one line and truncated to
+	// 256 bytes.
+	StatusLevel slog.Leveler
+
+	// Mirrors receive every entry in the native protocol format in addition
+	// to the journal, one entry per Write call, for example to forward
+	// records to another logging system during a migration. Errors from
+	// mirrors are reported by LastError but do not fail Handle. Mirrors must
+	// be safe for concurrent use.
+	Mirrors []io.Writer
+
+	// DebugWriter, if set, receives an exact copy of every entry in the
+	// native protocol format before it is sent, which helps to find out why
+	// a field does not show up in journalctl. Every entry is passed in a
+	// single Write call. DebugWriter must be safe for concurrent use; its
+	// errors are ignored.
+	DebugWriter io.Writer
+
+	// RecentEntries keeps the last RecentEntries entries in memory, as
+	// returned by Handler.Recent, regardless of the log target. Zero
+	// disables it.
+	RecentEntries int
+
+	// Fallback handles all records on platforms without a journal, which is
+	// every platform but Linux. If nil, the handler writes to the Windows
+	// Event Log, syslogd or structured lines on standard error there.
+	Fallback slog.Handler
+
+	// RequireJournal makes NewHandler fail with ErrNoJournal on platforms
+	// without a journal instead of falling back.
+	RequireJournal bool
+
+	// Addr is the path of the socket to send entries to. It overrides
+	// Namespace and is mostly useful to log to a fake journal in tests.
+	// An address starting with '@' or a NUL byte names a socket in the
+	// abstract namespace, which needs no writable directory.
+	Addr string
+
+	// Identifier is sent as the SYSLOG_IDENTIFIER field. It defaults to the
+	// base name of the program.
+	Identifier string
+
+	// Documentation is a URL, such as a runbook link, sent as the
+	// DOCUMENTATION field of every record, which journalctl shows to
+	// operators. See also [Handler.WithDocumentation].
+	Documentation string
+
+	// LevelOverrides replaces Level for handlers created with WithGroup or
+	// given a logger name (see NameKey). The keys are group paths as passed
+	// to WithGroup, joined by dots, or dotted logger names, such as "server"
+	// or "server.http". The longest matching path wins, so one noisy
+	// subsystem can log at WARN while the rest of the program logs DEBUG.
+	// Overrides are resolved once when the handler is derived, keeping
+	// Enabled cheap.
+	LevelOverrides map[string]slog.Leveler
+
+	// LevelToPriority maps a record's level to the journal priority. If nil,
+	// each level is mapped to the priority of the closest named level at or
+	// below it. Facility bits in the result, such as syslog.LOG_LOCAL0, are
+	// ignored, since the journal only accepts priorities 0 to 7.
+	LevelToPriority func(slog.Level) Priority
+
+	// AddLevel adds a LEVEL field with the name of the record's level, such
+	// as INFO or NOTICE, next to the numeric PRIORITY field.
+	AddLevel bool
+
+	// Verbosity treats levels below slog.LevelInfo as klog/logr style
+	// verbosity levels, where V(n) is logged at slog.Level(-n). Such records
+	// get priority LOG_DEBUG, regardless of LevelToPriority, and a VERBOSITY
+	// field holding n.
+	Verbosity bool
+
+	// RecordJSON adds a RECORD_JSON field holding the whole record, with
+	// its time, level, message and attributes, as a JSON object like
+	// [slog.JSONHandler] writes it. Groups are kept as nested objects, so
+	// pipelines exporting the journal to JSON-based systems get a lossless
+	// representation next to the flattened fields.
+	RecordJSON bool
+
+	// TimestampFormat selects the format of the SYSLOG_TIMESTAMP field. The
+	// default is the traditional syslog format.
+	TimestampFormat TimestampFormat
+
+	// MonotonicTimestamp adds a MONOTONIC_TIMESTAMP field holding the
+	// CLOCK_MONOTONIC time in microseconds at which Handle was called, the
+	// clock journald's own __MONOTONIC_TIMESTAMP uses. Unlike the latter it
+	// is not affected by delays in an async queue or the memfd fallback,
+	// which helps ordering analysis. It is left out on platforms without
+	// CLOCK_MONOTONIC.
+	MonotonicTimestamp bool
+
+	// OmitIdentifier, OmitTimestamp and OmitCode leave out the
+	// SYSLOG_IDENTIFIER, SYSLOG_TIMESTAMP and CODE_FILE, CODE_FUNC and
+	// CODE_LINE fields respectively, for the smallest possible datagrams.
+	// journald fills in _COMM and its own timestamps regardless.
+	OmitIdentifier bool
+	OmitTimestamp  bool
+	OmitCode       bool
+
 	// ReplaceAttr is called on all non-builtin Attrs before they are written.
 	// This can be useful for processing attributes to be in the correct format
 	// for log statements outside of your own code as the journal only accepts
@@ -85,10 +321,196 @@ type Options struct {
 	// log statements outside of your own code as the journal only accepts
 	// keys of the form ^[A-Z_][A-Z0-9_]*$.
 	ReplaceGroup func(group string) string
+
+	// DuplicateKeys controls how a key that appears more than once in a
+	// single record is handled. The default, DuplicateKeyAllow, sends every
+	// occurrence.
+	DuplicateKeys DuplicateKeyPolicy
+
+	// SortFields emits fields in a deterministic order: the fields the
+	// handler adds itself, such as MESSAGE, PRIORITY, CODE_* and SYSLOG_*,
+	// first, followed by all other fields sorted by key. By default fields
+	// are emitted in attribute order.
+	SortFields bool
+
+	// MaxValueLength limits the length in bytes of every field value. Longer
+	// values are truncated and a TRUNCATED field naming the affected key is
+	// added, so that at least a prefix of huge payloads is recorded instead of
+	// journald dropping the entire entry. Zero means no limit.
+	MaxValueLength int
+
+	// MaxRecordBytes limits the encoded size of a single record. Records
+	// that are larger are handled according to RecordOverflow instead of
+	// being sent through a memfd. Zero means no limit.
+	MaxRecordBytes int
+
+	// RecordOverflow selects whether records exceeding MaxRecordBytes are
+	// truncated (the default) or split into several linked entries.
+	RecordOverflow RecordOverflowPolicy
+
+	// Slices selects how slice and array values, except byte slices, are
+	// sent. By default their string form, such as "[a b c]", is sent, which
+	// cannot be queried by element.
+	Slices SliceEncoding
+
+	// MaxResolveDepth limits the number of nested LogValue calls made to
+	// resolve an attribute. Both chains of LogValuers returning LogValuers
+	// and LogValuers returning groups holding further LogValuers count, so a
+	// value that refers to itself cannot recurse forever. Values nested
+	// deeper are replaced by an error. Zero means 100, the limit slog
+	// applies to chains.
+	MaxResolveDepth int
+
+	// MaxResolvedBytes limits the encoded size of the fields produced by a
+	// single attribute whose value is a LogValuer. If they exceed it, they
+	// are replaced by a single field holding an error, so that a value
+	// expanding into megabytes does not end up in the journal. Zero means
+	// no limit.
+	MaxResolvedBytes int
+
+	// ExpandMaps sends map values with string keys, such as
+	// map[string]string or map[string]any, like groups: every entry becomes
+	// a field named KEY_SUBKEY, with the entry's key rewritten by
+	// SanitizeKey, instead of a single field holding the map's string form.
+	// Entries are sent sorted by key.
+	ExpandMaps bool
+
+	// MaxGroupDepth limits the number of groups, added with WithGroup or
+	// as group attributes, that are spelled out in field names. Deep
+	// nesting otherwise produces long names that journald may reject.
+	// Groups beyond the limit are handled according to GroupOverflow. Zero
+	// means no limit.
+	MaxGroupDepth int
+
+	// GroupOverflow selects whether groups nested deeper than
+	// MaxGroupDepth are sent as a JSON value (the default) or as a hashed
+	// segment of the field names.
+	GroupOverflow GroupOverflowPolicy
+
+	// LongKeys controls what happens to field names longer than
+	// [MaxKeyLength], which journald would otherwise reject. Such names
+	// easily occur with deeply nested groups. By default the field is dropped.
+	LongKeys LongKeyPolicy
+
+	// WarnInvalidKeys, if set, is called with every field name journald
+	// would reject, such as "request.id" or a name starting with an
+	// underscore, even if InvalidKeys rewrites it, so that such fields are noticed during development
+	// rather than silently missing in production. It is called from
+	// Handle and WithAttrs and must be safe for concurrent use.
+	WarnInvalidKeys func(key string)
+
+	// FieldMap maps attribute keys, such as "trace_id" or "msg_id", to the
+	// journal field they are sent as, such as TRACE_ID or MESSAGE_ID,
+	// regardless of the groups they are in, so existing call sites need no
+	// changes. Keys are looked up after ReplaceAttr; the field names are
+	// used as is, without applying InvalidKeys or the other key policies.
+	FieldMap map[string]string
+
+	// InvalidKeys controls what happens to field names journald would
+	// reject. By default they are sent as is and journald drops them.
+	InvalidKeys InvalidKeyPolicy
+
+	// UnderscoreKeys controls what happens to field names starting with an
+	// underscore, which journald reserves for trusted fields and drops when
+	// sent by a client. Renamed fields are followed by a RENAMED field
+	// holding the original name. By default they are handled like any other
+	// invalid key.
+	UnderscoreKeys UnderscoreKeyPolicy
+
+	// InvalidUTF8 controls how values that are not valid UTF-8 are sent. By
+	// default they are sent as is.
+	InvalidUTF8 InvalidUTF8Policy
+
+	// EscapeNewlines replaces newlines in values with the two characters
+	// `\n` so that every field is sent as a KEY=VALUE line. This is meant for
+	// collectors speaking the native protocol that do not implement its
+	// length-prefixed binary form. The escaping is not reversible.
+	// InvalidUTF8Binary has no effect in this mode.
+	EscapeNewlines bool
+
+	// QueueSize enables asynchronous delivery. If positive, records are
+	// encoded by the caller of Handle and sent to the journal by a background
+	// goroutine, with up to QueueSize entries waiting in a queue. Records
+	// arriving while the queue is full are dropped and counted in
+	// Stats.Dropped, so logging never blocks the caller. Delivery errors are
+	// reported by LastError instead of Handle. Call Shutdown before the
+	// program exits to send the queued entries.
+	QueueSize int
+
+	// BatchSize is the largest number of queued entries the background
+	// goroutine sends with a single sendmmsg(2) call when QueueSize is set,
+	// saving a system call per entry under load. Zero means 64; 1 sends
+	// every entry on its own. Batching only happens on Linux.
+	BatchSize int
+
+	// FlushInterval is how long the background goroutine waits for more
+	// entries to fill a batch when QueueSize is set. An entry sits in the
+	// queue for at most FlushInterval, plus the time taken to send the
+	// entries ahead of it, so low-rate services still see their entries
+	// promptly in journalctl -f. Zero sends queued entries as soon as
+	// possible, batching only those that are already waiting.
+	FlushInterval time.Duration
+
+	// QueueBytes limits the memory taken by the entries waiting in the
+	// queue when QueueSize is set, so that a journald outage cannot make
+	// the process grow without bound. Records that would take the queue
+	// past QueueBytes are dropped and counted in Stats.Dropped, like those
+	// arriving while the queue is full. Zero means no limit besides
+	// QueueSize.
+	QueueBytes int64
+
+	// NonBlocking sends entries without waiting for room in the socket
+	// buffer. Entries journald is too busy to accept are dropped and counted
+	// in Stats.Dropped instead of blocking the caller, which suits
+	// latency-critical services that must never stall on logging.
+	NonBlocking bool
+
+	// SendRetries is the number of times a datagram is sent again when the
+	// kernel reports ENOBUFS, before the entry is sent through a memfd.
+	// Under transient memory pressure this avoids creating a memfd for
+	// every small entry. Zero means no retries. SendRetries is ignored
+	// with NonBlocking, as waiting between retries would block the caller.
+	SendRetries int
+
+	// SendRetryBackoff is the time to wait before the first retry. It is
+	// doubled for every further retry. The default is one millisecond.
+	SendRetryBackoff time.Duration
+
+	// ForceSendBuffer enlarges the socket send buffer with SO_SNDBUFFORCE,
+	// like sd-journal does, so bursts of entries are buffered beyond the
+	// net.core.wmem_max limit without changing sysctls. This requires
+	// CAP_NET_ADMIN, usually by running as root; without it the regular,
+	// limited buffer size is kept.
+	ForceSendBuffer bool
+}
+
+// priority returns the journal priority for records at level l.
+func (h *Handler) priority(l slog.Level) Priority {
+	if h.opts.Verbosity && l < slog.LevelInfo {
+		return logDebug
+	}
+	if f := h.opts.LevelToPriority; f != nil {
+		return f(l) & 0x07
+	}
+	return LevelToPriority(l)
 }
 
 // Handler sends logs to the systemd journal.
 // The journal only accepts keys of the form ^[A-Z_][A-Z0-9_]*$.
+//
+// A struct passed as an attribute value is sent as a group of fields if any
+// of its fields has a journal struct tag, much like encoding/json tags:
+//
+//	type Request struct {
+//		Method string `journal:"METHOD"`
+//		Path   string `journal:"PATH,omitempty"`
+//		Body   []byte `journal:"-"`
+//	}
+//
+// slog.Any("REQ", req) then adds the fields REQ_METHOD and REQ_PATH, and
+// slog.Any("", req) adds METHOD and PATH. Untagged exported fields are named
+// in upper snake case, so a field UserID becomes USER_ID. Structs without
+// journal tags are sent as a single field holding their string form.
 type Handler struct {
 	opts Options
 	// NOTE: We only do single Write() calls. Either the message fits in a
@@ -96,19 +518,61 @@ type Handler struct {
 	// makes writes atomic and thus we do not need any additional
 	// synchronization.
 	w            io.Writer
+	targets      *logTargets
+	stats        *stats
+	recent       *ring
 	groups       []string
 	prefix       string
 	preformatted []byte
+	// path is the dotted list of group names as passed to WithGroup, and
+	// level the entry of opts.LevelOverrides that applies to it, if any.
+	path  string
+	level slog.Leveler
+	// name is the logger name, see NameKey.
+	name string
+	// identifier overrides the SYSLOG_IDENTIFIER field if not nil.
+	identifier []byte
+	// documentation is sent as the DOCUMENTATION field if not nil.
+	documentation []byte
+	// limiter enforces Options.RateLimitInterval if not nil.
+	limiter *rateLimiter
+	// keys interns the keyInfo of field names if not nil.
+	keys *keyCache
+	// fieldMap holds the field names of Options.FieldMap.
+	fieldMap map[string]*keyInfo
+	// goas are the groups and attributes added to the handler, kept only
+	// if Options.RecordJSON is set.
+	goas []groupOrAttrs
+	// source reports whether preformatted holds CODE_* fields taken from a
+	// slog.Source attribute, which replace those derived from the PC.
+	source bool
+	// fallback handles all records if not nil, see Options.Fallback.
+	fallback slog.Handler
 }
 
 const sndBufSize = 8 * 1024 * 1024
 
+// journalSocket returns the path of the native protocol socket of the given
+// journal namespace.
+func journalSocket(namespace string) string {
+	if namespace == "" {
+		return "/run/systemd/journal/socket"
+	}
+	return "/run/systemd/journal." + namespace + "/socket"
+}
+
 // NewHandler returns a new Handler that writes to the [systemd journal].
 // The journal only accepts keys of the form ^[A-Z_][A-Z0-9_]*$.
 // If opts is nil, the default options are used.
 // If opts.Level is nil, the default level is a [LevelVar] which is equivalent to
 // slog.LevelInfo unless the environment variable DEBUG_INVOCATION is set, in
-// which case it is slog.LevelDebug.
+// which case it is slog.LevelDebug, or SYSTEMD_LOG_LEVEL names another level.
+//
+// Only Linux has a journal. Elsewhere, unless opts.Addr is set, records go
+// to opts.Fallback if set, and otherwise to the Windows Event Log on
+// Windows, syslogd on the BSDs and structured lines on standard error on
+// other platforms. Set opts.RequireJournal to fail with [ErrNoJournal]
+// instead.
 //
 // [systemd journal]: https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
 func NewHandler(opts *Options) (*Handler, error) {
@@ -119,25 +583,110 @@ func NewHandler(opts *Options) (*Handler, error) {
 	}
 
 	if h.opts.Level == nil {
-		h.opts.Level = &LevelVar{}
+		h.opts.Level = NewLevelVar()
 	}
 
-	w, err := newJournalWriter()
-	if err != nil {
+	addr := h.opts.Addr
+	if addr == "" {
+		addr = journalSocket(h.opts.Namespace)
+	}
+	h.stats = &stats{}
+	h.recent = newRing(h.opts.RecentEntries)
+	var w *journalWriter
+	err := ErrNoJournal
+	if hasJournal || h.opts.Addr != "" {
+		w, err = h.newJournalWriter(addr)
+	}
+	switch {
+	case errors.Is(err, ErrNoJournal) && h.opts.RequireJournal:
+		return nil, err
+	case errors.Is(err, ErrNoJournal) && h.opts.Fallback != nil:
+		h.fallback = h.opts.Fallback
+	case errors.Is(err, ErrNoJournal):
+		// There is no journal on this platform. Write to the platform's
+		// native log instead so that one constructor works everywhere.
+		ident := h.opts.Identifier
+		if ident == "" {
+			ident = string(identifier)
+		}
+		h.w = newFallbackWriter(ident)
+	case err != nil:
 		return nil, err
+	default:
+		h.w = w
+	}
+	if len(h.opts.ExtraNamespaces) > 0 && w != nil {
+		ws := fanoutWriter{w}
+		for _, ns := range h.opts.ExtraNamespaces {
+			w, err := h.newJournalWriter(journalSocket(ns))
+			if err != nil {
+				closeJournalWriters(ws)
+				return nil, err
+			}
+			ws = append(ws, w)
+		}
+		h.w = ws
+	}
+	h.targets = newLogTargets()
+	h.keys = &keyCache{}
+	h.fieldMap = newFieldMap(h.opts.FieldMap)
+	if h.opts.Identifier != "" {
+		h.identifier = []byte(h.opts.Identifier)
+	}
+	if h.opts.Documentation != "" {
+		h.documentation = []byte(h.opts.Documentation)
+	}
+	if h.opts.RateLimitInterval > 0 && h.opts.RateLimitBurst > 0 {
+		h.limiter = newRateLimiter(h.opts.RateLimitInterval, h.opts.RateLimitBurst)
+	}
+	for _, r := range h.opts.Routes {
+		if err := h.checkRoute(r); err != nil {
+			closeJournalWriters(journalWriters(h.w))
+			return nil, err
+		}
+	}
+	if h.opts.QueueSize > 0 {
+		h.w = newAsyncWriter(h.w, h.opts.QueueSize, h.opts.BatchSize, h.opts.FlushInterval, h.opts.QueueBytes, h.stats)
 	}
-
-	h.w = w
 
 	return h, nil
 
 }
 
+// newJournalWriter returns a writer for the socket at addr configured
+// according to h.opts.
+func (h *Handler) newJournalWriter(addr string) (*journalWriter, error) {
+	w, err := newJournalWriter(addr)
+	if err != nil {
+		return nil, err
+	}
+	w.stats = h.stats
+	if h.opts.ForceSendBuffer {
+		// Keep the size set by newJournalWriter if we are not privileged.
+		_ = forceSendBuffer(w.conn, sndBufSize)
+	}
+	w.nonblock = h.opts.NonBlocking
+	if !w.nonblock {
+		w.retries = h.opts.SendRetries
+	}
+	w.backoff = h.opts.SendRetryBackoff
+	if w.backoff <= 0 {
+		w.backoff = time.Millisecond
+	}
+	return w, nil
+}
+
 // Enabled reports whether the handler handles records at the given level.
 // The handler ignores records whose level is lower.
 // It is called early, before any arguments are processed,
 // to save effort if the log event should be discarded.
-func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.fallback != nil {
+		return h.fallback.Enabled(ctx, level)
+	}
+	if h.level != nil {
+		return level >= h.level.Level()
+	}
 	return level >= h.opts.Level.Level()
 }
 
@@ -145,16 +694,24 @@ var identifier = []byte(path.Base(os.Args[0]))
 
 // Handle handles the Record and formats it as a [journal message].
 // The Message field maps to the [MESSAGE] field in the journal.
-// The Level field maps to the [PRIORITY] field in the journal.
-// The PC field maps to the [CODE_FILE, CODE_FUNC and CODE_LINE] fields in the journal.
-// The Time field maps to the [SYSLOG_TIMESTAMP] field in the journal.
+// The Level field maps to the [PRIORITY] field in the journal, and to a LEVEL field if Options.AddLevel is set.
+// The PC field maps to the [CODE_FILE, CODE_FUNC and CODE_LINE] fields in the journal, unless Options.OmitCode is set.
+// An attribute holding a *slog.Source or slog.Source sets these fields instead of the PC.
+// The Time field maps to the [SYSLOG_TIMESTAMP] field in the journal, formatted according to Options.TimestampFormat,
+// unless Options.OmitTimestamp is set.
 // The Attrs field maps to the [KEY=VALUE] fields in the journal.
-// The [SYSLOG_IDENTIFIER] field is set to the base name of the program.
+// The [SYSLOG_IDENTIFIER] field is set to an [IdentifierKey] attribute of the record, Options.Identifier,
+// or the base name of the program, unless Options.OmitIdentifier is set.
+// A request ID carried by ctx, see [ContextWithRequestID], maps to a REQUEST_ID field.
 // Journal only supports keys of the form ^[A-Z_][A-Z0-9_]*$.
-// Keys starting with an underscore are reserved for internal use and will be dropped.
+// Keys starting with an underscore are reserved for internal use and will be dropped,
+// unless Options.UnderscoreKeys renames them.
 // Any other keys will be silently dropped.
+// Keys longer than [MaxKeyLength] are handled according to Options.LongKeys.
+// Other invalid keys are handled according to Options.InvalidKeys, and
+// Options.WarnInvalidKeys is told about each of them.
 //
-// Message keys may appear multiple times.
+// Message keys may appear multiple times, unless Options.DuplicateKeys says otherwise.
 // Message values may contain arbitrary binary data.
 // If the message does not fit in a single datagram, the message is sent as a file descriptor pointing to a tempfd.
 // If the tempfd feature is not available, the message is sent as a file descriptor pointing to a temporary file in /dev/shm.
@@ -166,55 +723,335 @@ var identifier = []byte(path.Base(os.Args[0]))
 // [SYSLOG_TIMESTAMP]: https://www.freedesktop.org/software/systemd/man/latest/systemd.journal-fields.html#SYSLOG_FACILITY=
 // [SYSLOG_IDENTIFIER]: https://www.freedesktop.org/software/systemd/man/latest/systemd.journal-fields.html#SYSLOG_FACILITY=
 func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
-	buf := make([]byte, 0, 1024)
-	buf = h.appendKV(buf, "MESSAGE", []byte(r.Message))
-	buf = h.appendKV(buf, "PRIORITY", []byte(strconv.Itoa(int(levelToPriority(r.Level)))))
+	if h.fallback != nil {
+		return h.fallback.Handle(ctx, r)
+	}
+	var mono int64
+	var monoOK bool
+	if h.opts.MonotonicTimestamp {
+		mono, monoOK = monotonicUsec()
+	}
+	if h.stats != nil {
+		h.stats.records.Add(1)
+	}
+	if h.limiter != nil {
+		key, id := h.rateLimitKey(r)
+		if !h.limiter.allow(h, key, id, time.Now()) {
+			if h.stats != nil {
+				h.stats.dropped.Add(1)
+			}
+			return nil
+		}
+	}
+	msg := r.Message
+	if h.opts.MessageTemplates && strings.Contains(msg, "{") {
+		attrs := make([]slog.Attr, 0, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+		msg = h.renderMessage(msg, attrs)
+	}
+	if l := h.opts.StatusLevel; l != nil && r.Level >= l.Level() {
+		_ = notifyStatus(msg)
+	}
+	var stack [smallBufferSize]byte
+	buf := stack[:0]
+	if len(msg)+len(h.preformatted) > smallBufferSize/2 {
+		bp := getBuffer()
+		defer putBuffer(bp)
+		buf = (*bp)[:0]
+	}
+	buf = h.appendValue(buf, keyMessage, []byte(msg))
+	// num holds numbers formatted for a single field.
+	var num [20]byte
+	buf = h.appendValue(buf, keyPriority, strconv.AppendInt(num[:0], int64(h.priority(r.Level)), 10))
+	if h.opts.AddLevel {
+		buf = h.appendValue(buf, keyLevel, []byte(levelName(r.Level)))
+	}
+	if h.opts.Verbosity && r.Level < slog.LevelInfo {
+		buf = h.appendValue(buf, keyVerbosity, strconv.AppendInt(num[:0], int64(slog.LevelInfo-r.Level), 10))
+	}
+	// A slog.Source attribute takes the place of the PC.
+	code := r.PC != 0 && !h.opts.OmitCode && !h.source
+	ra := h.scanAttrs(r, code)
 	// If r.PC is zero, ignore it.
-	if r.PC != 0 {
+	if code && !ra.source {
 		fs := runtime.CallersFrames([]uintptr{r.PC})
 		f, _ := fs.Next()
-		buf = h.appendKV(buf, "CODE_FILE", []byte(f.File))
-		buf = h.appendKV(buf, "CODE_FUNC", []byte(f.Function))
-		buf = h.appendKV(buf, "CODE_LINE", []byte(strconv.Itoa(f.Line)))
+		buf = h.appendValue(buf, keyCodeFile, []byte(f.File))
+		buf = h.appendValue(buf, keyCodeFunc, []byte(f.Function))
+		buf = h.appendValue(buf, keyCodeLine, strconv.AppendInt(num[:0], int64(f.Line), 10))
 	}
 
 	// If r.Time is the zero time, ignore the time.
 	// NOTE: journald does its own timestamping. Lets just ignore
 	// NOTE: slogtest requires this. grrr
-	if !r.Time.IsZero() {
-		timestampStr := strconv.FormatInt(r.Time.UnixMicro(), 10)
-		buf = h.appendKV(buf, "SYSLOG_TIMESTAMP", []byte(timestampStr))
+	if !r.Time.IsZero() && !h.opts.OmitTimestamp {
+		var ts [32]byte
+		buf = h.appendValue(buf, keySyslogTimestamp, appendTimestamp(ts[:0], r.Time, h.opts.TimestampFormat))
+	}
+	if monoOK {
+		buf = h.appendValue(buf, keyMonotonicTimestamp, strconv.AppendInt(num[:0], mono, 10))
 	}
 
-	buf = h.appendKV(buf, "SYSLOG_IDENTIFIER", identifier)
+	ident := h.identifier
+	if ident == nil {
+		ident = identifier
+	}
+	recordIdent := ra.ident != nil
+	if recordIdent {
+		ident = ra.ident
+	}
+	if !h.opts.OmitIdentifier {
+		buf = h.appendValue(buf, keySyslogIdentifier, ident)
+	}
+
+	if h.name != "" {
+		buf = h.appendValue(buf, keyName, []byte(h.name))
+	}
+
+	if h.documentation != nil {
+		buf = h.appendValue(buf, keyDocumentation, h.documentation)
+	}
+
+	if tp := traceparent(ctx, ra.traceparent); tp != "" {
+		if traceID, spanID, flags, ok := parseTraceparent(tp); ok {
+			buf = h.appendValue(buf, keyTraceID, []byte(traceID))
+			buf = h.appendValue(buf, keySpanID, []byte(spanID))
+			buf = h.appendValue(buf, keyTraceFlags, []byte(flags))
+		}
+	}
+
+	if id := requestID(ctx, ra.requestID); id != "" {
+		buf = h.appendValue(buf, keyRequestID, []byte(id))
+	}
 
-	buf = append(buf, h.preformatted...)
+	// The fields so far are the handler's own, which MaxRecordBytes keeps
+	// in every entry.
+	head := len(buf)
 
+	// Large preformatted attributes are passed to the journal as a
+	// separate buffer rather than copied into every record.
+	split := -1
+	if len(h.preformatted) >= minVectoredSize && h.canWriteVectored() {
+		split = len(buf)
+	} else {
+		buf = append(buf, h.preformatted...)
+	}
+
+	// Field names are assembled in a second buffer to avoid allocating a
+	// string for every prefixed key.
+	kp := getBuffer()
+	defer putBuffer(kp)
+	prefix := append((*kp)[:0], h.prefix...)
+	if h.deepGroups() {
+		attrs := make([]slog.Attr, 0, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			if !recordIdent || !isIdentifierAttr(h.groups, a) {
+				attrs = append(attrs, a)
+			}
+			return true
+		})
+		buf = h.appendDeepAttrs(buf, prefix, attrs)
+	} else {
+		r.Attrs(func(a slog.Attr) bool {
+			if !recordIdent || !isIdentifierAttr(h.groups, a) {
+				buf = h.appendAttr(buf, prefix, len(h.groups), 0, a)
+			}
+			return true
+		})
+	}
+
+	if h.opts.RecordJSON {
+		jp := getBuffer()
+		defer putBuffer(jp)
+		*jp = h.appendRecordJSON((*jp)[:0], r, msg)
+		buf = h.appendValue(buf, keyRecordJSON, *jp)
+	}
+
+	if jw, ok := h.w.(*journalWriter); ok && split < 0 && h.writesDirect() {
+		n, err := jw.writeStack(buf)
+		h.countWrite(n, err)
+		return err
+	}
+
+	// Everything else may hand the record to arbitrary writers, so it is
+	// sent from a pooled copy to keep the stack array on the stack.
+	cp := getBuffer()
+	defer putBuffer(cp)
+	rec := append((*cp)[:0], buf...)
+	defer func() { *cp = rec }()
+
+	if split >= 0 {
+		return h.writeVectored(rec[:split], h.preformatted, rec[split:])
+	}
+
+	if h.opts.DuplicateKeys != DuplicateKeyAllow || h.opts.SortFields {
+		rec, head = h.rewriteFields(rec, head)
+	}
+
+	if max := h.opts.MaxRecordBytes; max > 0 && len(rec) > max {
+		for _, b := range h.limitRecord(rec, head) {
+			if err := h.write(r.Level, b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return h.write(r.Level, rec)
+
+}
+
+// recordAttrs holds what Handle needs to know about the attributes of a
+// record before encoding them.
+type recordAttrs struct {
+	// source reports whether an attribute holds a source location.
+	source bool
+	// ident is the value of an [IdentifierKey] attribute, if any.
+	ident []byte
+	// traceparent is the value of the first [TraceparentKey] attribute.
+	traceparent string
+	// requestID reports whether there is a [RequestIDKey] attribute.
+	requestID bool
+}
+
+// scanAttrs collects the recordAttrs of r in a single pass over its
+// attributes. Source locations are only looked for if source is set.
+func (h *Handler) scanAttrs(r slog.Record, source bool) recordAttrs {
+	var ra recordAttrs
 	r.Attrs(func(a slog.Attr) bool {
-		buf = h.appendAttr(buf, h.prefix, a)
+		switch {
+		case isIdentifierAttr(h.groups, a):
+			ra.ident = []byte(a.Value.String())
+		case a.Key == RequestIDKey:
+			ra.requestID = true
+		case ra.traceparent == "" && strings.EqualFold(a.Key, TraceparentKey):
+			ra.traceparent = a.Value.Resolve().String()
+		}
+		if source && !ra.source {
+			ra.source = hasSource(a)
+		}
 		return true
 	})
-
-	_, err := h.w.Write(buf)
-	return err
-
+	return ra
 }
 
+// appendKV appends the field k=v to b, applying the key and value limits
+// configured in the handler's options.
 func (h *Handler) appendKV(b []byte, k string, v []byte) []byte {
-	if bytes.IndexByte(v, '\n') != -1 {
-		b = append(b, k...)
-		b = append(b, '\n')
-		b = binary.LittleEndian.AppendUint64(b, uint64(len(v)))
-		b = append(b, v...)
-	} else {
-		b = append(b, k...)
-		b = append(b, '=')
+	ki := h.keyInfo(k)
+	if ki.invalid {
+		h.warnInvalidKey(k)
+	}
+	if ki.key == "" {
+		return b
+	}
+	return h.appendValue(b, ki, v)
+}
+
+// appendValue appends the field named by ki with value v to b, applying the
+// value limits configured in the handler's options.
+func (h *Handler) appendValue(b []byte, ki *keyInfo, v []byte) []byte {
+	if h.opts.InvalidUTF8 == InvalidUTF8Replace && !utf8.Valid(v) {
+		v = bytes.ToValidUTF8(v, []byte(string(utf8.RuneError)))
+	}
+	if h.opts.EscapeNewlines && bytes.IndexByte(v, '\n') != -1 {
+		v = bytes.ReplaceAll(v, []byte{'\n'}, []byte(`\n`))
+	}
+	switch max := h.opts.MaxValueLength; {
+	case max > 0 && len(v) > max:
+		b = h.encodeKV(b, ki.key, truncateValue(v, max))
+		b = h.encodeKV(b, "TRUNCATED", []byte(ki.key))
+	case bytes.IndexByte(v, '\n') == -1 && (h.opts.InvalidUTF8 != InvalidUTF8Binary || utf8.Valid(v)):
+		b = append(b, ki.eq...)
 		b = append(b, v...)
 		b = append(b, '\n')
+	default:
+		b = h.encodeKV(b, ki.key, v)
+	}
+	if ki.renamed != "" {
+		b = h.encodeKV(b, "RENAMED", []byte(ki.renamed))
+	}
+	return b
+}
+
+// appendAttrKV appends the field named k with the attribute value v to b.
+// The value is formatted directly into b; only values that need to be
+// rewritten according to the handler's options take the slower path
+// through appendValue.
+func (h *Handler) appendAttrKV(b, k []byte, v slog.Value) []byte {
+	ki := h.keyInfoBytes(k)
+	if ki.invalid {
+		h.warnInvalidKey(string(k))
+	}
+	if ki.key == "" {
+		return b
+	}
+	return h.appendAttrValue(b, ki, v)
+}
+
+// appendAttrValue appends the field named by ki with the attribute value v
+// to b.
+func (h *Handler) appendAttrValue(b []byte, ki *keyInfo, v slog.Value) []byte {
+	start := len(b)
+	b = append(b, ki.eq...)
+	b = appendValueText(b, v)
+	val := b[start+len(ki.eq):]
+	if bytes.IndexByte(val, '\n') != -1 ||
+		h.opts.MaxValueLength > 0 && len(val) > h.opts.MaxValueLength ||
+		h.opts.InvalidUTF8 != InvalidUTF8Pass && !utf8.Valid(val) {
+		return h.appendValue(b[:start], ki, bytes.Clone(val))
+	}
+	b = append(b, '\n')
+	if ki.renamed != "" {
+		b = h.encodeKV(b, "RENAMED", []byte(ki.renamed))
 	}
 	return b
 }
 
+// appendValueText appends the text form of v to b. Durations and times are
+// sent in microseconds.
+func appendValueText(b []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return append(b, v.String()...)
+	case slog.KindInt64:
+		return strconv.AppendInt(b, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(b, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return strconv.AppendFloat(b, v.Float64(), 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(b, v.Bool())
+	case slog.KindDuration:
+		return strconv.AppendInt(b, v.Duration().Microseconds(), 10)
+	case slog.KindTime:
+		return strconv.AppendInt(b, v.Time().UnixMicro(), 10)
+	}
+	return append(b, v.String()...)
+}
+
+// encodeKV appends the field k=v to b using the native protocol framing.
+func (h *Handler) encodeKV(b []byte, k string, v []byte) []byte {
+	forceBinary := h.opts.InvalidUTF8 == InvalidUTF8Binary && !h.opts.EscapeNewlines && !utf8.Valid(v)
+	return appendField(b, k, v, forceBinary)
+}
+
+// truncateValue shortens v to at most max bytes without splitting a UTF-8
+// encoded rune.
+func truncateValue(v []byte, max int) []byte {
+	n := max
+	for i := 0; i < utf8.UTFMax-1 && n > 0 && !utf8.RuneStart(v[n]); i++ {
+		n--
+	}
+	if n == 0 {
+		n = max
+	}
+	return v[:n]
+}
+
 // appendAttr has the following rules:
 //   - Attr's values should be resolved.
 //   - If an Attr's key and value are both the zero value, ignore the Attr.
@@ -222,15 +1059,38 @@ func (h *Handler) appendKV(b []byte, k string, v []byte) []byte {
 //   - If a group's key is empty, inline the group's Attrs.
 //   - If a group has no Attrs (even if it has a non-empty key),
 //     ignore it.
-func (h *Handler) appendAttr(b []byte, prefix string, a slog.Attr) []byte {
+//
+// depth is the number of groups a is nested in, see Options.MaxGroupDepth,
+// and valuers the number of LogValue calls, see Options.MaxResolveDepth.
+func (h *Handler) appendAttr(b []byte, prefix []byte, depth, valuers int, a slog.Attr) []byte {
+	start, base, key := len(b), slices.Clip(prefix), a.Key
+	valuer := a.Value.Kind() == slog.KindLogValuer
 	// Attr's values should be resolved.
-	a.Value = a.Value.Resolve()
+	var err error
+	if a.Value, valuers, err = h.resolve(a.Value, valuers); err != nil {
+		a.Value = slog.AnyValue(err)
+	}
 
 	if rep := h.opts.ReplaceAttr; rep != nil && a.Value.Kind() != slog.KindGroup {
 		// a.Value is resolved before calling ReplaceAttr, so the user doesn't have to.
 		a = rep(h.groups, a)
 		// The ReplaceAttr function may return an unresolved Attr.
-		a.Value = a.Value.Resolve()
+		if a.Value, valuers, err = h.resolve(a.Value, valuers); err != nil {
+			a.Value = slog.AnyValue(err)
+		}
+	}
+
+	// Structs with journal tags are sent as a group of fields, maps too if
+	// Options.ExpandMaps is set, and slices according to Options.Slices.
+	// Expanding counts like a LogValue call, so self-referential values
+	// stop at Options.MaxResolveDepth and are formatted with fmt instead.
+	if a.Value.Kind() == slog.KindAny {
+		if attrs, ok := h.expandAttrs(a.Value.Any(), valuers); ok {
+			a.Value = slog.GroupValue(attrs...)
+			valuers++
+		} else if h.opts.Slices != SliceString {
+			a.Value = encodeSlice(a.Value, h.opts.Slices)
+		}
 	}
 
 	// If an Attr's key and value are both the zero value, ignore the Attr.
@@ -250,31 +1110,77 @@ func (h *Handler) appendAttr(b []byte, prefix string, a slog.Attr) []byte {
 			if rep := h.opts.ReplaceGroup; rep != nil {
 				a.Key = rep(a.Key)
 			}
-			prefix += a.Key + "_"
+			switch {
+			case !h.groupOverflow(depth):
+				// Appending to prefix leaves the caller's view of it intact.
+				prefix = append(prefix, a.Key...)
+				prefix = append(prefix, '_')
+			case h.opts.GroupOverflow == GroupOverflowHash:
+				prefix = h.overflowPrefix(prefix, depth, a.Key)
+			default:
+				return h.appendGroupJSON(b, append(prefix, a.Key...), append(slices.Clip(h.groups), a.Key), attrs)
+			}
+			depth++
 		}
-		for _, a := range attrs {
-			b = h.appendAttr(b, prefix, a)
+		for _, ga := range attrs {
+			b = h.appendAttr(b, prefix, depth, valuers, ga)
+			if valuer && h.tooLarge(b, start) {
+				break
+			}
 		}
-	case slog.KindDuration:
-		b = h.appendKV(b, prefix+a.Key, []byte(strconv.FormatInt(a.Value.Duration().Microseconds(), 10)))
-	case slog.KindTime:
-		b = h.appendKV(b, prefix+a.Key, []byte(strconv.FormatInt(a.Value.Time().UnixMicro(), 10)))
 	default:
-		b = h.appendKV(b, prefix+a.Key, []byte(a.Value.String()))
+		if s, ok := sourceOf(a.Value); ok {
+			return h.appendSource(b, s)
+		}
+		if ki := h.fieldMap[a.Key]; ki != nil {
+			b = h.appendAttrValue(b, ki, a.Value)
+		} else {
+			b = h.appendAttrKV(b, append(prefix, a.Key...), a.Value)
+		}
 	}
 
+	// Fields produced by a LogValuer are replaced as a whole if they
+	// exceed Options.MaxResolvedBytes.
+	if valuer && h.tooLarge(b, start) {
+		err := fmt.Errorf("slogjournal: LogValue produced more than %d bytes", h.opts.MaxResolvedBytes)
+		b = h.appendAttrKV(b[:start], append(base, key...), slog.AnyValue(err))
+	}
 	return b
 }
 
 // WithAttrs returns a new Handler whose attributes consist of
 // both the receiver's attributes and the arguments.
+// A top-level string attribute with key [NameKey] sets the logger name instead,
+// and one with key [IdentifierKey] the SYSLOG_IDENTIFIER.
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	h2 := *h
+	if h.fallback != nil {
+		h2.fallback = h.fallback.WithAttrs(attrs)
+		return &h2
+	}
 	pre := slices.Clone(h2.preformatted)
+	var deep []slog.Attr
 	for _, a := range attrs {
-		pre = h2.appendAttr(pre, h2.prefix, a)
+		if len(h2.groups) == 0 && a.Key == NameKey && a.Value.Kind() == slog.KindString {
+			h2.setName(a.Value.String())
+			continue
+		}
+		if isIdentifierAttr(h2.groups, a) {
+			h2.identifier = []byte(a.Value.String())
+			continue
+		}
+		if h2.deepGroups() {
+			deep = append(deep, a)
+			continue
+		}
+		pre = h2.appendAttr(pre, []byte(h2.prefix), len(h2.groups), 0, a)
+		h2.source = h2.source || hasSource(a)
 	}
+	pre = h2.appendDeepAttrs(pre, []byte(h2.prefix), deep)
 	h2.preformatted = pre
+	if h.opts.RecordJSON {
+		h2.goas = withGroupOrAttrs(h.goas, groupOrAttrs{attrs: slices.Clone(attrs)})
+	}
 	return &h2
 }
 
@@ -284,16 +1190,150 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 	if name == "" {
 		return h
 	}
+	if h.fallback != nil {
+		h2 := *h
+		h2.fallback = h.fallback.WithGroup(name)
+		return &h2
+	}
+	path := name
+	if h.path != "" {
+		path = h.path + "." + name
+	}
+	level := h.level
+	if l, ok := h.opts.LevelOverrides[path]; ok {
+		level = l
+	}
+	goas := h.goas
+	if h.opts.RecordJSON {
+		goas = withGroupOrAttrs(goas, groupOrAttrs{group: name})
+	}
 	if rep := h.opts.ReplaceGroup; rep != nil {
 		name = rep(name)
 	}
+	prefix := h.prefix + name + "_"
+	if h.groupOverflow(len(h.groups)) {
+		if h.opts.GroupOverflow == GroupOverflowHash {
+			prefix = string(h.overflowPrefix([]byte(h.prefix), len(h.groups), name))
+		} else {
+			prefix = h.prefix
+		}
+	}
 	return &Handler{
-		opts:         h.opts,
-		w:            h.w,
-		groups:       append(slices.Clip(h.groups), name),
-		prefix:       h.prefix + name + "_",
-		preformatted: h.preformatted,
+		opts:          h.opts,
+		w:             h.w,
+		targets:       h.targets,
+		stats:         h.stats,
+		recent:        h.recent,
+		groups:        append(slices.Clip(h.groups), name),
+		prefix:        prefix,
+		preformatted:  h.preformatted,
+		path:          path,
+		level:         level,
+		name:          h.name,
+		identifier:    h.identifier,
+		documentation: h.documentation,
+		limiter:       h.limiter,
+		keys:          h.keys,
+		fieldMap:      h.fieldMap,
+		goas:          goas,
+		source:        h.source,
+	}
+}
+
+// WithOptions returns a new Handler with the options changed by update,
+// which is called with a copy of the receiver's options. The new handler
+// keeps the receiver's groups and attributes and shares its connection,
+// log target and statistics, so subsystems can use their own Level,
+// ReplaceAttr or Identifier without opening another socket. Attributes
+// added before are not encoded again.
+//
+// Options that configure the connection or the handler's fallback are
+// ignored: Addr, Namespace, ExtraNamespaces, QueueSize, BatchSize,
+// FlushInterval, QueueBytes, NonBlocking, SendRetries, SendRetryBackoff,
+// ForceSendBuffer, Routes, RecentEntries, Fallback and RequireJournal.
+func (h *Handler) WithOptions(update func(*Options)) *Handler {
+	h2 := *h
+	opts := h.opts
+	opts.LevelOverrides = maps.Clone(h.opts.LevelOverrides)
+	opts.Mirrors = slices.Clone(h.opts.Mirrors)
+	update(&opts)
+	opts.Addr = h.opts.Addr
+	opts.Namespace = h.opts.Namespace
+	opts.ExtraNamespaces = h.opts.ExtraNamespaces
+	opts.QueueSize = h.opts.QueueSize
+	opts.BatchSize = h.opts.BatchSize
+	opts.FlushInterval = h.opts.FlushInterval
+	opts.QueueBytes = h.opts.QueueBytes
+	opts.NonBlocking = h.opts.NonBlocking
+	opts.SendRetries = h.opts.SendRetries
+	opts.SendRetryBackoff = h.opts.SendRetryBackoff
+	opts.ForceSendBuffer = h.opts.ForceSendBuffer
+	opts.Routes = h.opts.Routes
+	opts.RecentEntries = h.opts.RecentEntries
+	opts.Fallback = h.opts.Fallback
+	opts.RequireJournal = h.opts.RequireJournal
+	if opts.Level == nil {
+		opts.Level = h.opts.Level
+	}
+	h2.opts = opts
+
+	h2.level = nil
+	if l, ok := opts.LevelOverrides[h.path]; ok && h.path != "" {
+		h2.level = l
+	}
+	if opts.Identifier != h.opts.Identifier {
+		h2.identifier = nil
+		if opts.Identifier != "" {
+			h2.identifier = []byte(opts.Identifier)
+		}
+	}
+	if opts.Documentation != h.opts.Documentation {
+		h2.documentation = nil
+		if opts.Documentation != "" {
+			h2.documentation = []byte(opts.Documentation)
+		}
+	}
+	if opts.RateLimitInterval != h.opts.RateLimitInterval || opts.RateLimitBurst != h.opts.RateLimitBurst {
+		h2.limiter = nil
+		if opts.RateLimitInterval > 0 && opts.RateLimitBurst > 0 {
+			h2.limiter = newRateLimiter(opts.RateLimitInterval, opts.RateLimitBurst)
+		}
+	}
+	// Cached field names depend on the key policies.
+	h2.keys = &keyCache{}
+	h2.fieldMap = newFieldMap(opts.FieldMap)
+	return &h2
+}
+
+// CloneWithWriter returns a copy of h, with the same options, groups and
+// attributes, that sends entries to w instead of the journal, for example
+// to mirror a subsystem's records to a test journal or a capture buffer.
+// Every entry is passed to w in a single Write call. The clone has its own
+// log target, initially LogTargetJournal meaning w, and its own statistics
+// and recent entries.
+// Options.Routes, Mirrors and DebugWriter still apply.
+func (h *Handler) CloneWithWriter(w io.Writer) *Handler {
+	h2 := *h
+	h2.w = w
+	h2.fallback = nil
+	h2.targets = newLogTargets()
+	h2.stats = &stats{}
+	h2.recent = newRing(h.opts.RecentEntries)
+	return &h2
+}
+
+// CloneWithAddr is like CloneWithWriter but sends entries to the journal
+// socket at addr. The socket honours NonBlocking, SendRetries and
+// ForceSendBuffer like the receiver's; QueueSize and ExtraNamespaces are
+// ignored.
+func (h *Handler) CloneWithAddr(addr string) (*Handler, error) {
+	h2 := h.CloneWithWriter(nil)
+	w, err := h2.newJournalWriter(addr)
+	if err != nil {
+		return nil, err
 	}
+	h2.w = w
+	return h2, nil
 }
 
 var _ slog.Handler = &Handler{}
@@ -0,0 +1,4 @@
+package slogjournal
+
+// hasJournal reports whether the platform runs journald.
+const hasJournal = true
@@ -5,7 +5,13 @@ package slogjournal
 import (
 	"context"
 	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+
+	"golang.org/x/sys/unix"
 )
 
 func TestCanWriteMessageToJournal(t *testing.T) {
@@ -18,3 +24,113 @@ func TestCanWriteMessageToJournal(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func sendBufferSize(t *testing.T, h *Handler) int {
+	t.Helper()
+	rc, err := h.w.(*journalWriter).conn.SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size int
+	var serr error
+	if err := rc.Control(func(fd uintptr) {
+		size, serr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_SNDBUF)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if serr != nil {
+		t.Fatal(serr)
+	}
+	return size
+}
+
+func TestForceSendBuffer(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("SO_SNDBUFFORCE requires CAP_NET_ADMIN")
+	}
+	wmemMax, err := os.ReadFile("/proc/sys/net/core/wmem_max")
+	if err != nil {
+		t.Skip(err)
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(string(wmemMax)))
+	if err != nil || max >= sndBufSize {
+		t.Skip("net.core.wmem_max does not limit the send buffer")
+	}
+
+	h, err := NewHandler(&Options{ForceSendBuffer: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The kernel reports twice the requested size to account for overhead.
+	if size := sendBufferSize(t, h); size < 2*sndBufSize {
+		t.Errorf("expected a send buffer of %d bytes, got %d", 2*sndBufSize, size)
+	}
+}
+
+// openFds returns the file descriptors open in the process.
+func openFds(t *testing.T) []int {
+	t.Helper()
+	dir, err := os.Open("/proc/self/fd")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer dir.Close()
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fds []int
+	for _, name := range names {
+		if fd, err := strconv.Atoi(name); err == nil && fd != int(dir.Fd()) {
+			fds = append(fds, fd)
+		}
+	}
+	return fds
+}
+
+func TestNewHandlerClosesSockets(t *testing.T) {
+	// Initialize the network poller, which keeps descriptors of its own.
+	h, err := NewHandler(&Options{Addr: "@slogjournal-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeJournalWriters(journalWriters(h.w))
+
+	var lim unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NOFILE, &lim); err != nil {
+		t.Fatal(err)
+	}
+	before := openFds(t)
+	// Leave room for the first socket, and for the second one but not the
+	// descriptor net.FileConn duplicates it into.
+	limit := uint64(0)
+	for free := 0; free < 2; limit++ {
+		if !slices.Contains(before, int(limit)) {
+			free++
+		}
+	}
+	low := lim
+	low.Cur = limit
+	if err := unix.Setrlimit(unix.RLIMIT_NOFILE, &low); err != nil {
+		t.Skip(err)
+	}
+	_, err = NewHandler(&Options{Addr: "@slogjournal-test", ExtraNamespaces: []string{"audit"}})
+	if err := unix.Setrlimit(unix.RLIMIT_NOFILE, &lim); err != nil {
+		t.Fatal(err)
+	}
+	if err == nil {
+		t.Fatal("expected NewHandler to run out of descriptors")
+	}
+	if after := openFds(t); len(after) != len(before) {
+		t.Errorf("NewHandler leaked descriptors: %v before, %v after", before, after)
+	}
+
+	// Sockets are closed when a route is invalid, too.
+	_, err = NewHandler(&Options{Addr: "@slogjournal-test", Routes: []Route{{Target: "bogus"}}})
+	if err == nil {
+		t.Fatal("expected an invalid route to fail")
+	}
+	if after := openFds(t); len(after) != len(before) {
+		t.Errorf("NewHandler leaked descriptors: %v before, %v after", before, after)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package slogjournal
+
+// hasJournal reports whether the platform runs journald. Only Linux does,
+// so elsewhere the handler writes to the fallback unless Options.Addr is
+// set explicitly.
+const hasJournal = false
@@ -0,0 +1,29 @@
+//go:build !linux
+
+package slogjournal
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestFallback(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{Fallback: slog.NewJSONHandler(buf, nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.New(h).With("a", 1).WithGroup("g").Info("hello", "b", 2)
+	if got := buf.String(); !strings.Contains(got, `"msg":"hello","a":1,"g":{"b":2}`) {
+		t.Errorf("unexpected fallback output %q", got)
+	}
+}
+
+func TestRequireJournal(t *testing.T) {
+	if _, err := NewHandler(&Options{RequireJournal: true}); !errors.Is(err, ErrNoJournal) {
+		t.Errorf("expected ErrNoJournal, got %v", err)
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"encoding/binary"
 	"io"
 	"log/slog"
-	"net"
 	"os"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
-	"syscall"
+	"sync"
 	"testing"
 	"testing/slogtest"
 	"time"
@@ -254,138 +256,760 @@ func TestSlogtest(t *testing.T) {
 	})
 }
 
-func TestCanWriteMessageToSocket(t *testing.T) {
-	tempDir, err := os.MkdirTemp(os.TempDir(), "journal")
-	if err != nil {
-		t.Fatal(err)
+func TestLevel(t *testing.T) {
+	l := LevelVar{}
+	if l.Level() != slog.LevelInfo {
+		t.Error("expected LevelInfo")
 	}
-	addr := tempDir + "/socket"
-	raddr, err := net.ResolveUnixAddr("unixgram", addr)
+
+	h, err := NewHandler(nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	conn, err := net.ListenUnixgram("unixgram", raddr)
+	if h.opts.Level.Level() != slog.LevelInfo {
+		t.Error("expected LevelInfo")
+	}
+
+	l = LevelVar{}
+	os.Setenv("DEBUG_INVOCATION", "1")
+	if l.Level() != slog.LevelDebug {
+		t.Error("expected LevelDebug")
+	}
+
+	h, err = NewHandler(nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer conn.Close()
+	if h.opts.Level.Level() != slog.LevelDebug {
+		t.Error("expected LevelDebug")
+	}
 
-	handler, err := NewHandler(nil)
+	h, err = NewHandler(&Options{Level: slog.LevelError})
 	if err != nil {
 		t.Fatal(err)
 	}
+	if h.opts.Level.Level() != slog.LevelError {
+		t.Error("expected LevelError")
+	}
 
-	handler.w.(*journalWriter).addr = raddr
-
-	t.Run("NormalSize", func(t *testing.T) {
-		if err := handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: "Hello, World!"}); err != nil {
-			t.Fatal(err)
-		}
+	if !h.Enabled(context.TODO(), slog.LevelError) {
+		t.Error("expected true")
+	}
 
-		buf := make([]byte, 1024)
-		oob := make([]byte, 1024)
+}
 
-		n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+func TestDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		policy DuplicateKeyPolicy
+		want   []Field
+	}{
+		{DuplicateKeyAllow, []Field{{"KEY", []byte("a")}, {"KEY", []byte("b")}, {"KEY", []byte("c")}}},
+		{DuplicateKeyFirstWins, []Field{{"KEY", []byte("a")}}},
+		{DuplicateKeyLastWins, []Field{{"KEY", []byte("c")}}},
+		{DuplicateKeyIndex, []Field{{"KEY", []byte("a")}, {"KEY_1", []byte("b")}, {"KEY_2", []byte("c")}}},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		handler, err := NewHandler(&Options{DuplicateKeys: tt.policy})
 		if err != nil {
 			t.Fatal(err)
 		}
-		if n == 0 {
-			t.Error("no data read")
+		handler.w = buf
+		record := slog.NewRecord(time.Time{}, slog.LevelInfo, "Hello, World!", 0)
+		record.AddAttrs(slog.String("KEY", "a"), slog.String("KEY", "b"), slog.String("KEY", "c"))
+
+		_ = handler.Handle(context.TODO(), record)
+		var got []Field
+		for _, f := range parseFields(buf.Bytes()) {
+			if strings.HasPrefix(f.Key, "KEY") {
+				got = append(got, f)
+			}
 		}
-		if oobn != 0 {
-			t.Error("did not expect oob data")
+		if len(got) != len(tt.want) {
+			t.Fatalf("policy %d: got %d fields, want %d: %q", tt.policy, len(got), len(tt.want), got)
 		}
-	})
+		for i := range got {
+			if got[i].Key != tt.want[i].Key || !bytes.Equal(got[i].Value, tt.want[i].Value) {
+				t.Errorf("policy %d: field %d = %s=%s, want %s=%s", tt.policy, i, got[i].Key, got[i].Value, tt.want[i].Key, tt.want[i].Value)
+			}
+		}
+	}
+}
+
+func TestDuplicateKeyIndexTaken(t *testing.T) {
+	fs := []Field{{"KEY", []byte("a")}, {"KEY_1", []byte("b")}, {"KEY", []byte("c")}, {"KEY", []byte("d")}, {"KEY_1", []byte("e")}}
+	var got []string
+	fs, _ = applyDuplicateKeyPolicy(fs, 0, DuplicateKeyIndex)
+	for _, f := range fs {
+		got = append(got, f.Key+"="+string(f.Value))
+	}
+	want := []string{"KEY=a", "KEY_1=b", "KEY_2=c", "KEY_3=d", "KEY_1_1=e"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
 
-	t.Run("TooLarge", func(t *testing.T) {
+func TestParseFieldsBinary(t *testing.T) {
+	h := &Handler{}
+	b := h.appendKV(nil, "A", []byte("one"))
+	b = h.appendKV(b, "B", []byte("two\nlines"))
+	b = h.appendKV(b, "C", []byte("three"))
+	fs := parseFields(b)
+	if len(fs) != 3 || string(fs[1].Value) != "two\nlines" || fs[2].Key != "C" {
+		t.Errorf("unexpected fields: %q", fs)
+	}
+}
 
-		_ = handler.w.(*journalWriter).conn.SetWriteBuffer(1024)
+func TestSortFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{SortFields: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	h2 := handler.WithAttrs([]slog.Attr{slog.String("ZULU", "z")})
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(slog.String("BRAVO", "b"), slog.String("ALPHA", "a"))
+
+	_ = h2.Handle(context.TODO(), record)
+	var keys []string
+	for _, f := range parseFields(buf.Bytes()) {
+		keys = append(keys, f.Key)
+	}
+	want := []string{"MESSAGE", "PRIORITY", "SYSLOG_TIMESTAMP", "SYSLOG_IDENTIFIER", "ALPHA", "BRAVO", "ZULU"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("got %v, want %v", keys, want)
+	}
+}
+
+func TestMaxValueLength(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{MaxValueLength: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "short", 0)
+	record.AddAttrs(slog.String("LONG", "0123456789abcdef"), slog.String("RUNES", "aaaaaaa€"))
 
-		largeLog := "Hello, World!"
-		for range 1024 {
-			largeLog += "a"
+	_ = handler.Handle(context.TODO(), record)
+	kv, err := deserializeKeyValue(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["MESSAGE"] != "short" {
+		t.Error("unexpected message", kv)
+	}
+	if kv["LONG"] != "01234567" {
+		t.Error("expected LONG to be truncated", kv)
+	}
+	if kv["RUNES"] != "aaaaaaa" {
+		t.Error("expected RUNES to be truncated at a rune boundary", kv)
+	}
+	var truncated []string
+	for _, f := range parseFields(buf.Bytes()) {
+		if f.Key == "TRUNCATED" {
+			truncated = append(truncated, string(f.Value))
 		}
+	}
+	if !slices.Contains(truncated, "LONG") || !slices.Contains(truncated, "RUNES") {
+		t.Error("unexpected TRUNCATED fields", truncated)
+	}
+}
 
-		if err := handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: largeLog}); err != nil {
-			t.Fatal(err)
+// entryWriter records every Write call as a separate journal entry.
+type entryWriter struct {
+	mu      sync.Mutex
+	entries [][]byte
+}
+
+func (w *entryWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries = append(w.entries, slices.Clone(p))
+	return len(p), nil
+}
+
+// messages returns the MESSAGE of every entry written so far.
+func (w *entryWriter) messages() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var msgs []string
+	for _, e := range w.entries {
+		for _, f := range parseFields(e) {
+			if f.Key == "MESSAGE" {
+				msgs = append(msgs, string(f.Value))
+			}
 		}
+	}
+	return msgs
+}
 
-		buf := make([]byte, 1024)
-		oob := make([]byte, 1024)
+func TestMaxRecordBytes(t *testing.T) {
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "Hello, World!", 0)
+	for _, k := range []string{"A", "B", "C", "D"} {
+		record.AddAttrs(slog.String(k, strings.Repeat(k, 40)))
+	}
 
-		_, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	t.Run("Truncate", func(t *testing.T) {
+		w := &entryWriter{}
+		handler, err := NewHandler(&Options{MaxRecordBytes: 200})
 		if err != nil {
-			t.Error(err)
+			t.Fatal(err)
 		}
+		handler.w = w
+		if err := handler.Handle(context.TODO(), record); err != nil {
+			t.Fatal(err)
+		}
+		if len(w.entries) != 1 {
+			t.Fatalf("expected 1 entry, got %d", len(w.entries))
+		}
+		if len(w.entries[0]) > 200 {
+			t.Errorf("entry is %d bytes", len(w.entries[0]))
+		}
+		kv, err := deserializeKeyValue(bytes.NewReader(w.entries[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kv["MESSAGE"] != "Hello, World!" || kv["DROPPED_FIELDS"] == "" {
+			t.Error("unexpected entry", kv)
+		}
+	})
 
-		ctrl, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	t.Run("Split", func(t *testing.T) {
+		w := &entryWriter{}
+		handler, err := NewHandler(&Options{MaxRecordBytes: 200, RecordOverflow: RecordOverflowSplit})
 		if err != nil {
-			t.Error(err)
+			t.Fatal(err)
+		}
+		handler.w = w
+		if err := handler.Handle(context.TODO(), record); err != nil {
+			t.Fatal(err)
+		}
+		if len(w.entries) < 2 {
+			t.Fatalf("expected several entries, got %d", len(w.entries))
+		}
+		seen := map[string]bool{}
+		var id string
+		for i, e := range w.entries {
+			if len(e) > 200 {
+				t.Errorf("entry %d is %d bytes", i, len(e))
+			}
+			kv, err := deserializeKeyValue(bytes.NewReader(e))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kv["MESSAGE"] != "Hello, World!" {
+				t.Error("expected MESSAGE in every chunk", kv)
+			}
+			if i == 0 {
+				id = kv["CHUNK_ID"]
+			}
+			if kv["CHUNK_ID"] != id || kv["CHUNK_INDEX"] != strconv.Itoa(i) || kv["CHUNK_COUNT"] != strconv.Itoa(len(w.entries)) {
+				t.Error("unexpected chunk fields", kv)
+			}
+			for _, k := range []string{"A", "B", "C", "D"} {
+				if _, ok := kv[k]; ok {
+					seen[k] = true
+				}
+			}
 		}
+		if len(seen) != 4 {
+			t.Error("not all fields were sent", seen)
+		}
+	})
+}
 
-		for _, m := range ctrl {
-			rights, err := syscall.ParseUnixRights(&m)
+func TestMaxRecordBytesHead(t *testing.T) {
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, strings.Repeat("m", 500), 0)
+	record.AddAttrs(slog.String("A", "a"))
+	for _, policy := range []RecordOverflowPolicy{RecordOverflowTruncate, RecordOverflowSplit} {
+		w := &entryWriter{}
+		handler, err := NewHandler(&Options{Level: slog.LevelInfo, MaxRecordBytes: 200, RecordOverflow: policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.w = w
+		if err := handler.Handle(context.TODO(), record); err != nil {
+			t.Fatal(err)
+		}
+		for i, e := range w.entries {
+			if len(e) > 200 {
+				t.Errorf("policy %d: entry %d is %d bytes", policy, i, len(e))
+			}
+			kv, err := deserializeKeyValue(bytes.NewReader(e))
 			if err != nil {
-				t.Error(err)
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(kv["MESSAGE"], "mmm") || kv["TRUNCATED"] != "MESSAGE" || kv["PRIORITY"] == "" {
+				t.Errorf("policy %d: unexpected entry %v", policy, kv)
+			}
+		}
+	}
+}
+
+func TestMaxRecordBytesBuiltinNames(t *testing.T) {
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "hello", 0)
+	record.AddAttrs(slog.String("NAME", strings.Repeat("n", 150)), slog.String("REQUEST_ID", strings.Repeat("r", 150)))
+
+	w := &entryWriter{}
+	handler, err := NewHandler(&Options{Level: slog.LevelInfo, MaxRecordBytes: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = w
+	if err := handler.Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.entries) != 1 || len(w.entries[0]) > 200 {
+		t.Fatalf("unexpected entries %q", w.entries)
+	}
+	kv, err := deserializeKeyValue(bytes.NewReader(w.entries[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["MESSAGE"] != "hello" || kv["DROPPED_FIELDS"] != "2" || kv["NAME"] != "" || kv["REQUEST_ID"] != "" {
+		t.Error("unexpected entry", kv)
+	}
+}
+
+func TestMaxRecordBytesCompanions(t *testing.T) {
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "hello", 0)
+	for _, k := range []string{"A", "B", "C", "D"} {
+		record.AddAttrs(slog.String(k, strings.Repeat(k, 40)))
+	}
+	w := &entryWriter{}
+	handler, err := NewHandler(&Options{
+		Level:          slog.LevelInfo,
+		MaxValueLength: 30,
+		MaxRecordBytes: 200,
+		RecordOverflow: RecordOverflowSplit,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = w
+	if err := handler.Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.entries) < 2 {
+		t.Fatalf("expected several entries, got %d", len(w.entries))
+	}
+	for i, e := range w.entries {
+		fs := parseFields(e)
+		for j, f := range fs {
+			if len(f.Key) == 1 && (j+1 == len(fs) || fs[j+1].Key != "TRUNCATED" || string(fs[j+1].Value) != f.Key) {
+				t.Errorf("entry %d: %s is not followed by its TRUNCATED field", i, f.Key)
 			}
-			for _, fd := range rights {
-				_ = syscall.SetNonblock(int(fd), true)
-				f := os.NewFile(uintptr(fd), "journal")
-				defer f.Close()
-				_, _ = f.Seek(0, 0)
-				buf := make([]byte, 4096)
-				n, err := f.Read(buf)
-				if err != nil {
-					t.Fatal(err)
+		}
+	}
+}
+
+func TestLongKeys(t *testing.T) {
+	long := strings.Repeat("K", MaxKeyLength+10)
+	other := strings.Repeat("K", MaxKeyLength+5)
+	tests := []struct {
+		policy LongKeyPolicy
+		check  func(t *testing.T, kv map[string]string)
+	}{
+		{LongKeyDrop, func(t *testing.T, kv map[string]string) {
+			for k := range kv {
+				if len(k) > MaxKeyLength || strings.HasPrefix(k, "KKK") {
+					t.Error("expected long key to be dropped", k)
 				}
-				if n == 0 {
-					t.Error("no data read")
+			}
+		}},
+		{LongKeyTruncate, func(t *testing.T, kv map[string]string) {
+			if kv[long[:MaxKeyLength]] == "" {
+				t.Error("expected truncated key", kv)
+			}
+		}},
+		{LongKeyHash, func(t *testing.T, kv map[string]string) {
+			n := 0
+			for k := range kv {
+				if strings.HasPrefix(k, "KKK") {
+					n++
+					if len(k) != MaxKeyLength {
+						t.Error("unexpected key length", k)
+					}
 				}
 			}
+			if n != 2 {
+				t.Error("expected two distinct hashed keys", kv)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		handler, err := NewHandler(&Options{LongKeys: tt.policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.w = buf
+		record := slog.NewRecord(time.Time{}, slog.LevelInfo, "Hello, World!", 0)
+		record.AddAttrs(slog.String(long, "a"), slog.String(other, "b"))
+		_ = handler.Handle(context.TODO(), record)
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kv["MESSAGE"] != "Hello, World!" {
+			t.Error("unexpected message", kv)
 		}
+		tt.check(t, kv)
+	}
+}
 
-	})
+func TestInvalidUTF8(t *testing.T) {
+	invalid := "ok\xff\xfeok"
+
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{InvalidUTF8: InvalidUTF8Replace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(slog.String("VALUE", invalid))
+	_ = handler.Handle(context.TODO(), record)
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["VALUE"] != "ok�ok" {
+		t.Errorf("unexpected value %q", kv["VALUE"])
+	}
 
+	buf.Reset()
+	handler.opts.InvalidUTF8 = InvalidUTF8Binary
+	_ = handler.Handle(context.TODO(), record)
+	if !bytes.Contains(buf.Bytes(), []byte("VALUE\n")) {
+		t.Errorf("expected binary encoding, got %q", buf.Bytes())
+	}
+	kv, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["VALUE"] != invalid {
+		t.Errorf("unexpected value %q", kv["VALUE"])
+	}
 }
 
-func TestLevel(t *testing.T) {
-	l := LevelVar{}
-	if l.Level() != slog.LevelInfo {
-		t.Error("expected LevelInfo")
+func TestEscapeNewlines(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{EscapeNewlines: true, SortFields: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "line one\nline two", 0)
+	record.AddAttrs(slog.String("VALUE", "a\nb\n"))
+	_ = handler.Handle(context.TODO(), record)
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if !strings.Contains(line, "=") {
+			t.Errorf("expected KEY=VALUE line, got %q", line)
+		}
+	}
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
 	}
+	if kv["MESSAGE"] != `line one\nline two` || kv["VALUE"] != `a\nb\n` {
+		t.Error("unexpected values", kv)
+	}
+}
 
-	h, err := NewHandler(nil)
+func TestLevelToPriority(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  Priority
+	}{
+		{slog.LevelDebug - 4, logDebug},
+		{slog.LevelDebug, logDebug},
+		{slog.LevelDebug + 1, logDebug},
+		{slog.LevelInfo, logInfo},
+		{LevelNotice, logNotice},
+		{LevelNotice + 1, logNotice},
+		{slog.LevelWarn, logWarning},
+		{slog.LevelWarn + 1, logWarning},
+		{slog.LevelError, logErr},
+		{LevelCritical, logCrit},
+		{LevelAlert, logAlert},
+		{LevelEmergency, logEmerg},
+		{LevelEmergency + 10, logEmerg},
+	}
+	for _, tt := range tests {
+		if got := LevelToPriority(tt.level); got != tt.want {
+			t.Errorf("LevelToPriority(%v) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestCustomLevelToPriority(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{LevelToPriority: func(l slog.Level) Priority {
+		if l < slog.LevelDebug {
+			return logDebug
+		}
+		return logAlert
+	}})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if h.opts.Level.Level() != slog.LevelInfo {
+	handler.w = buf
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "Hello, World!", 0))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["PRIORITY"] != "1" {
+		t.Error("unexpected priority", kv)
+	}
+}
+
+func TestAddLevel(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{AddLevel: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	for level, want := range map[slog.Level]string{
+		slog.LevelInfo:     "INFO",
+		LevelNotice:        "NOTICE",
+		slog.LevelWarn + 1: "WARN+1",
+		LevelEmergency:     "EMERGENCY",
+	} {
+		_ = handler.Handle(context.TODO(), slog.NewRecord(time.Time{}, level, "Hello, World!", 0))
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kv["LEVEL"] != want {
+			t.Errorf("level %d: got LEVEL=%q, want %q", level, kv["LEVEL"], want)
+		}
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{Level: slog.Level(-10), Verbosity: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.Level(-6), "Hello, World!", 0))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["PRIORITY"] != "7" || kv["VERBOSITY"] != "6" {
+		t.Error("unexpected fields", kv)
+	}
+
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "Hello, World!", 0))
+	kv, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := kv["VERBOSITY"]; ok || kv["PRIORITY"] != "6" {
+		t.Error("unexpected fields", kv)
+	}
+}
+
+func TestSystemdLogLevel(t *testing.T) {
+	t.Setenv("DEBUG_INVOCATION", "")
+	t.Setenv("SYSTEMD_LOG_LEVEL", "warning")
+	l := LevelVar{}
+	if l.Level() != slog.LevelWarn {
+		t.Error("expected LevelWarn")
+	}
+
+	t.Setenv("SYSTEMD_LOG_LEVEL", "bogus")
+	l = LevelVar{}
+	if l.Level() != slog.LevelInfo {
 		t.Error("expected LevelInfo")
 	}
 
+	t.Setenv("SYSTEMD_LOG_LEVEL", "err")
+	t.Setenv("DEBUG_INVOCATION", "1")
 	l = LevelVar{}
-	os.Setenv("DEBUG_INVOCATION", "1")
 	if l.Level() != slog.LevelDebug {
-		t.Error("expected LevelDebug")
+		t.Error("expected DEBUG_INVOCATION to take precedence")
+	}
+}
+
+func TestLevelVarResetReload(t *testing.T) {
+	t.Setenv("DEBUG_INVOCATION", "")
+	t.Setenv("SYSTEMD_LOG_LEVEL", "notice")
+	v := NewLevelVar()
+	// Changing the environment after construction has no effect until Reload.
+	t.Setenv("SYSTEMD_LOG_LEVEL", "err")
+	if v.Level() != LevelNotice {
+		t.Errorf("expected NOTICE, got %v", v.Level())
+	}
+	v.Set(slog.LevelDebug)
+	if v.Level() != slog.LevelDebug {
+		t.Errorf("expected DEBUG, got %v", v.Level())
+	}
+	v.Reset()
+	if v.Level() != LevelNotice {
+		t.Errorf("expected NOTICE after Reset, got %v", v.Level())
+	}
+	v.Reload()
+	if v.Level() != slog.LevelError {
+		t.Errorf("expected ERROR after Reload, got %v", v.Level())
 	}
 
-	h, err = NewHandler(nil)
+	// Set before first use must not be overwritten by the environment.
+	var w LevelVar
+	w.Set(slog.LevelWarn)
+	if w.Level() != slog.LevelWarn {
+		t.Errorf("expected WARN, got %v", w.Level())
+	}
+}
+
+func TestLevelOverrides(t *testing.T) {
+	h, err := NewHandler(&Options{
+		Level: slog.LevelDebug,
+		LevelOverrides: map[string]slog.Leveler{
+			"server":      slog.LevelWarn,
+			"server.http": slog.LevelInfo,
+		},
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if h.opts.Level.Level() != slog.LevelDebug {
-		t.Error("expected LevelDebug")
+	server := h.WithGroup("server")
+	http := server.WithGroup("http")
+	db := server.WithGroup("db")
+	other := h.WithGroup("other")
+
+	tests := []struct {
+		h     slog.Handler
+		level slog.Level
+		want  bool
+	}{
+		{h, slog.LevelDebug, true},
+		{other, slog.LevelDebug, true},
+		{server, slog.LevelInfo, false},
+		{server, slog.LevelWarn, true},
+		{http, slog.LevelDebug, false},
+		{http, slog.LevelInfo, true},
+		{db, slog.LevelInfo, false},
+		{db.WithAttrs([]slog.Attr{slog.String("A", "b")}), slog.LevelWarn, true},
+	}
+	for i, tt := range tests {
+		if got := tt.h.Enabled(context.TODO(), tt.level); got != tt.want {
+			t.Errorf("%d: Enabled(%v) = %v, want %v", i, tt.level, got, tt.want)
+		}
 	}
+}
 
-	h, err = NewHandler(&Options{Level: slog.LevelError})
+func TestDebugWriter(t *testing.T) {
+	journal := new(bytes.Buffer)
+	debug := new(bytes.Buffer)
+	h, err := NewHandler(&Options{DebugWriter: debug})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if h.opts.Level.Level() != slog.LevelError {
-		t.Error("expected LevelError")
+	h.w = journal
+	logger := slog.New(h).WithGroup("G")
+	logger.Info("hello", "KEY", "multi\nline")
+	if debug.Len() == 0 || !bytes.Equal(debug.Bytes(), journal.Bytes()) {
+		t.Errorf("expected a copy of %q, got %q", journal.Bytes(), debug.Bytes())
+	}
+}
+
+func TestWithOptions(t *testing.T) {
+	buf := new(entryWriter)
+	h, err := NewHandler(&Options{Identifier: "main", Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	parent := h.WithAttrs([]slog.Attr{slog.String("SHARED", "1")}).(*Handler)
+	child := parent.WithOptions(func(o *Options) {
+		o.Level = slog.LevelDebug
+		o.Identifier = "worker"
+		o.Addr = "/ignored"
+	})
+	if child.w != parent.w || child.opts.Addr != "" {
+		t.Error("expected the child to share the connection")
+	}
+	slog.New(parent).Debug("dropped")
+	slog.New(child).Debug("kept")
+	slog.New(parent).Info("parent")
+	if got := buf.messages(); !slices.Equal(got, []string{"kept", "parent"}) {
+		t.Fatalf("unexpected messages %q", got)
 	}
+	for i, want := range []string{"worker", "main"} {
+		kv, err := deserializeKeyValue(bytes.NewReader(buf.entries[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kv["SYSLOG_IDENTIFIER"] != want || kv["SHARED"] != "1" {
+			t.Errorf("entry %d: unexpected fields %v", i, kv)
+		}
+	}
+}
 
-	if !h.Enabled(context.TODO(), slog.LevelError) {
-		t.Error("expected true")
+func TestCloneWithWriter(t *testing.T) {
+	orig := new(bytes.Buffer)
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
 	}
+	h.w = orig
+	parent := h.WithAttrs([]slog.Attr{slog.String("A", "1")}).WithGroup("G").(*Handler)
+	shadow := new(bytes.Buffer)
+	clone := parent.CloneWithWriter(shadow)
+	slog.New(clone).Info("shadow", "B", "2")
+	if orig.Len() != 0 {
+		t.Errorf("unexpected output to the original writer %q", orig.Bytes())
+	}
+	kv, err := deserializeKeyValue(shadow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["MESSAGE"] != "shadow" || kv["A"] != "1" || kv["G_B"] != "2" {
+		t.Errorf("unexpected fields %v", kv)
+	}
+	if clone.Stats().Records != 1 || h.Stats().Records != 0 {
+		t.Error("expected separate statistics")
+	}
+
+}
 
+func TestOmitBuiltinFields(t *testing.T) {
+	pc, _, _, _ := runtime.Caller(0)
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "small", pc)
+	for _, tt := range []struct {
+		opts    Options
+		omitted []string
+	}{
+		{Options{}, nil},
+		{Options{OmitIdentifier: true}, []string{"SYSLOG_IDENTIFIER"}},
+		{Options{OmitTimestamp: true}, []string{"SYSLOG_TIMESTAMP"}},
+		{Options{OmitCode: true}, []string{"CODE_FILE", "CODE_FUNC", "CODE_LINE"}},
+	} {
+		buf := new(bytes.Buffer)
+		h, err := NewHandler(&tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.w = buf
+		if err := h.Handle(context.TODO(), r); err != nil {
+			t.Fatal(err)
+		}
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range []string{"SYSLOG_IDENTIFIER", "SYSLOG_TIMESTAMP", "CODE_FILE", "CODE_FUNC", "CODE_LINE"} {
+			if _, ok := kv[k]; ok == slices.Contains(tt.omitted, k) {
+				t.Errorf("%+v: %s present = %v", tt.opts, k, ok)
+			}
+		}
+	}
 }
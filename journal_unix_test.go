@@ -0,0 +1,285 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCanWriteMessageToSocket(t *testing.T) {
+	tempDir, err := os.MkdirTemp(os.TempDir(), "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := tempDir + "/socket"
+	raddr, err := net.ResolveUnixAddr("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.ListenUnixgram("unixgram", raddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.w.(*journalWriter).addr = raddr
+
+	t.Run("NormalSize", func(t *testing.T) {
+		if err := handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: "Hello, World!"}); err != nil {
+			t.Fatal(err)
+		}
+
+		buf := make([]byte, 1024)
+		oob := make([]byte, 1024)
+
+		n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			t.Error("no data read")
+		}
+		if oobn != 0 {
+			t.Error("did not expect oob data")
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+
+		_ = handler.w.(*journalWriter).conn.SetWriteBuffer(1024)
+
+		largeLog := "Hello, World!"
+		for range 1024 {
+			largeLog += "a"
+		}
+
+		if err := handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: largeLog}); err != nil {
+			t.Fatal(err)
+		}
+
+		buf := make([]byte, 1024)
+		oob := make([]byte, 1024)
+
+		_, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			t.Error(err)
+		}
+
+		ctrl, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Error(err)
+		}
+
+		for _, m := range ctrl {
+			rights, err := syscall.ParseUnixRights(&m)
+			if err != nil {
+				t.Error(err)
+			}
+			for _, fd := range rights {
+				_ = syscall.SetNonblock(int(fd), true)
+				f := os.NewFile(uintptr(fd), "journal")
+				defer f.Close()
+				_, _ = f.Seek(0, 0)
+				buf := make([]byte, 4096)
+				n, err := f.Read(buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n == 0 {
+					t.Error("no data read")
+				}
+			}
+		}
+
+	})
+
+}
+
+func TestNonBlocking(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Retrying would mean sleeping, so it is turned off.
+	h, err := NewHandler(&Options{Addr: addr, NonBlocking: true, SendRetries: 5, SendRetryBackoff: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := journalWriters(h.w)[0]; w.retries != 0 {
+		t.Errorf("expected no retries in non-blocking mode, got %d", w.retries)
+	}
+	// Nobody reads from conn, so the socket fills up eventually.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10000 && h.Stats().Dropped == 0; i++ {
+			if err := h.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "hello", 0)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Handle blocked")
+	}
+	if h.Stats().Dropped == 0 {
+		t.Error("expected records to be dropped")
+	}
+}
+
+func TestExtraNamespaces(t *testing.T) {
+	h, err := NewHandler(&Options{ExtraNamespaces: []string{"audit", ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var addrs []string
+	for _, w := range journalWriters(h.w) {
+		addrs = append(addrs, w.addr.Name)
+	}
+	want := []string{"/run/systemd/journal/socket", "/run/systemd/journal.audit/socket", "/run/systemd/journal/socket"}
+	if !slices.Equal(addrs, want) {
+		t.Errorf("expected %v, got %v", want, addrs)
+	}
+
+	// Send to two fake journals instead.
+	dir := t.TempDir()
+	var conns []*net.UnixConn
+	for i, w := range h.w.(fanoutWriter)[:2] {
+		addr := filepath.Join(dir, strconv.Itoa(i))
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+		w.addr = conn.LocalAddr().(*net.UnixAddr)
+	}
+	h.w = h.w.(fanoutWriter)[:2]
+	if err := h.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "twice", 0)); err != nil {
+		t.Fatal(err)
+	}
+	for _, conn := range conns {
+		buf := make([]byte, 1024)
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(buf[:n], []byte("MESSAGE=twice\n")) {
+			t.Errorf("unexpected entry %q", buf[:n])
+		}
+	}
+}
+
+func TestWriteVectored(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	h, err := NewHandler(&Options{Addr: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	big := strings.Repeat("x", 2*minVectoredSize)
+	logger := slog.New(h).With("BIG", big)
+	if !logger.Handler().(*Handler).canWriteVectored() {
+		t.Fatal("expected records to be sent vectored")
+	}
+
+	read := func() map[string]string {
+		t.Helper()
+		buf := make([]byte, 1<<20)
+		oob := make([]byte, 1024)
+		n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := buf[:n]
+		if oobn > 0 {
+			msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+			if err != nil {
+				t.Fatal(err)
+			}
+			fds, err := syscall.ParseUnixRights(&msgs[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := os.NewFile(uintptr(fds[0]), "journal")
+			defer f.Close()
+			if data, err = io.ReadAll(io.NewSectionReader(f, 0, 1<<30)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		kv, err := deserializeKeyValue(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return kv
+	}
+
+	logger.Info("vectored", "AFTER", "1")
+	kv := read()
+	if kv["MESSAGE"] != "vectored" || kv["BIG"] != big || kv["AFTER"] != "1" {
+		t.Errorf("unexpected entry %v", kv)
+	}
+
+	// Records too large for a datagram are assembled in a memfd.
+	_ = journalWriters(h.w)[0].conn.SetWriteBuffer(1024)
+	logger.Info("memfd", "HUGE", strings.Repeat("y", 1<<20))
+	kv = read()
+	if kv["MESSAGE"] != "memfd" || kv["BIG"] != big || len(kv["HUGE"]) != 1<<20 {
+		t.Errorf("unexpected entry with MESSAGE=%q", kv["MESSAGE"])
+	}
+}
+
+func TestCloneWithAddr(t *testing.T) {
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = new(bytes.Buffer)
+	parent := h.WithAttrs([]slog.Attr{slog.String("A", "1")}).WithGroup("G").(*Handler)
+
+	addr := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	clone, err := parent.CloneWithAddr(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.New(clone).Info("socket")
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf[:n], []byte("MESSAGE=socket\n")) {
+		t.Errorf("unexpected entry %q", buf[:n])
+	}
+}
@@ -2,89 +2,92 @@ package slogjournal
 
 import (
 	"errors"
-	"fmt"
 	"io"
 	"net"
-	"os"
-	"syscall"
+	"time"
 )
 
+// ErrNoJournal is returned by [NewHandler] if Options.RequireJournal is set
+// on a platform without a journal.
+var ErrNoJournal = errors.New("slogjournal: the journal is not available on this platform")
+
 // journalWriter encapsulates the behaviour of writing unixgrams to the journal socket.
 // It will try to write the message with a single write call, but if the message is too large
 // it will write the message to a temporary file and send the file descriptor as OOB data.
 type journalWriter struct {
-	addr *net.UnixAddr
-	conn *net.UnixConn
+	addr  *net.UnixAddr
+	conn  *net.UnixConn
+	stats *stats
+	// nonblock sends with MSG_DONTWAIT and drops entries that would block.
+	nonblock bool
+	// retries and backoff configure how often a datagram is resent after
+	// ENOBUFS before falling back to a memfd, see Options.SendRetries.
+	retries int
+	backoff time.Duration
 }
 
-func newJournalWriter() (io.Writer, error) {
-	// The "net" library in Go really wants me to either Dial or Listen a UnixConn,
-	// which would respectively bind() an address or connect() to a remote address,
-	// but we want neither. We want to create a datagram socket and write to it directly
-	// and not worry about reconnecting or rebinding.
-	// so jumping through some hoops here
-	fd, err := syscall.Socket(syscall.AF_UNIX, syscall.SOCK_DGRAM, 0)
-	if err != nil {
-		return nil, err
-	}
+var _ io.Writer = &journalWriter{}
 
-	f := os.NewFile(uintptr(fd), "journal")
-	defer f.Close()
+// fanoutWriter sends every entry to several journals, see
+// Options.ExtraNamespaces.
+type fanoutWriter []*journalWriter
 
-	fconn, err := net.FileConn(f)
-	if err != nil {
-		return nil, err
+// Write writes p to every journal, even if writing to one of them fails.
+func (f fanoutWriter) Write(p []byte) (int, error) {
+	var errs []error
+	for _, w := range f {
+		if _, err := w.Write(p); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	conn, ok := fconn.(*net.UnixConn)
-	if !ok {
-		return nil, fmt.Errorf("expected *net.UnixConn, got %T", fconn)
+	if len(errs) > 0 {
+		return 0, errors.Join(errs...)
 	}
+	return len(p), nil
+}
 
-	if err := conn.SetWriteBuffer(sndBufSize); err != nil {
-		return nil, err
+// WriteBuffers sends the concatenation of bufs to every journal, see
+// journalWriter.WriteBuffers.
+func (f fanoutWriter) WriteBuffers(bufs [][]byte) (int, error) {
+	var errs []error
+	n := 0
+	for _, w := range f {
+		var err error
+		if n, err = w.WriteBuffers(bufs); err != nil {
+			errs = append(errs, err)
+		}
 	}
-
-	addr := &net.UnixAddr{
-		Name: "/run/systemd/journal/socket",
-		Net:  "unixgram",
+	if len(errs) > 0 {
+		return 0, errors.Join(errs...)
 	}
-
-	return &journalWriter{
-		addr: addr,
-		conn: conn,
-	}, nil
+	return n, nil
 }
 
-// If the message is too large, it will write the message to a temporary file and send the file descriptor as OOB data.
-func (j *journalWriter) Write(p []byte) (n int, err error) {
-	// NOTE: No mutex needed. datagram socket writes are atomic
-	n, err = j.conn.WriteToUnix(p, j.addr)
-	// fail silently if the journal is not available
-	if err == nil || errors.Is(err, syscall.ENOENT) {
-		return n, nil
-	}
+// buffersWriter is implemented by writers that can send an entry assembled
+// from several buffers without concatenating them first.
+type buffersWriter interface {
+	WriteBuffers(bufs [][]byte) (int, error)
+}
 
-	if !errors.Is(err, syscall.ENOBUFS) && !errors.Is(err, syscall.EMSGSIZE) {
-		return n, err
+// closeJournalWriters closes the sockets of ws, for when NewHandler fails
+// after opening them.
+func closeJournalWriters(ws []*journalWriter) {
+	for _, w := range ws {
+		if w.conn != nil {
+			w.conn.Close()
+		}
 	}
+}
 
-	// Message does not fit in a single datagram, write to a temp file and send the file descriptor
-	file, err := tempFd()
-	if err != nil {
-		return n, err
-	}
-	defer file.Close()
-	if n, err := file.Write(p); err != nil {
-		return n, err
-	}
-	if err := trySeal(file); err != nil {
-		return n, err
+// journalWriters returns the writers for the journals w sends entries to.
+func journalWriters(w io.Writer) []*journalWriter {
+	switch w := w.(type) {
+	case *journalWriter:
+		return []*journalWriter{w}
+	case fanoutWriter:
+		return w
+	case *asyncWriter:
+		return journalWriters(w.w)
 	}
-	fd := int(file.Fd())
-	if _, _, err := j.conn.WriteMsgUnix([]byte{}, syscall.UnixRights(fd), j.addr); err != nil {
-		return 0, err
-	}
-	return n, err
+	return nil
 }
-
-var _ io.Writer = &journalWriter{}
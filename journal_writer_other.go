@@ -0,0 +1,20 @@
+//go:build !unix
+
+package slogjournal
+
+// newJournalWriter fails, as there is no journal on this platform.
+func newJournalWriter(string) (*journalWriter, error) {
+	return nil, ErrNoJournal
+}
+
+func (j *journalWriter) Write([]byte) (int, error) {
+	return 0, ErrNoJournal
+}
+
+func (j *journalWriter) writeStack([]byte) (int, error) {
+	return 0, ErrNoJournal
+}
+
+func (j *journalWriter) WriteBuffers([][]byte) (int, error) {
+	return 0, ErrNoJournal
+}
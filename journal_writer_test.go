@@ -5,7 +5,7 @@ import (
 )
 
 func TestJournalWriter(t *testing.T) {
-	_, err := newJournalWriter()
+	_, err := newJournalWriter(journalSocket(""))
 	if err != nil {
 		t.Fatal(err)
 	}
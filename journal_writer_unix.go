@@ -0,0 +1,209 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// newJournalWriter returns a writer sending datagrams to the socket at path.
+// A path starting with '@' or a NUL byte names a socket in the abstract
+// namespace.
+func newJournalWriter(path string) (*journalWriter, error) {
+	// The "net" library in Go really wants me to either Dial or Listen a UnixConn,
+	// which would respectively bind() an address or connect() to a remote address,
+	// but we want neither. We want to create a datagram socket and write to it directly
+	// and not worry about reconnecting or rebinding.
+	// so jumping through some hoops here
+	fd, err := syscall.Socket(syscall.AF_UNIX, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	f := os.NewFile(uintptr(fd), "journal")
+	defer f.Close()
+
+	fconn, err := net.FileConn(f)
+	if err != nil {
+		return nil, err
+	}
+	conn, ok := fconn.(*net.UnixConn)
+	if !ok {
+		return nil, fmt.Errorf("expected *net.UnixConn, got %T", fconn)
+	}
+
+	if err := conn.SetWriteBuffer(sndBufSize); err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(path, "\x00") {
+		path = "@" + path[1:]
+	}
+	addr := &net.UnixAddr{
+		Name: path,
+		Net:  "unixgram",
+	}
+
+	return &journalWriter{
+		addr: addr,
+		conn: conn,
+	}, nil
+}
+
+// If the message is too large, it will write the message to a temporary file and send the file descriptor as OOB data.
+func (j *journalWriter) Write(p []byte) (n int, err error) {
+	return j.writeEntry(p, nil)
+}
+
+// writeStack is Write for an entry in a buffer on the caller's stack. Write
+// lets p escape to the heap, so the common case of a blocking send that
+// succeeds, or fails because journald is not running, is handled here.
+// Everything else is passed to Write, which sends it again, with a pooled
+// copy of p.
+func (j *journalWriter) writeStack(p []byte) (int, error) {
+	if !j.nonblock {
+		n, _, err := j.conn.WriteMsgUnix(p, nil, j.addr)
+		if err == nil {
+			return n, nil
+		}
+		if errors.Is(err, syscall.ENOENT) {
+			if j.stats != nil {
+				j.stats.dropped.Add(1)
+			}
+			return 0, nil
+		}
+	}
+	cp := getBuffer()
+	defer putBuffer(cp)
+	*cp = append((*cp)[:0], p...)
+	return j.Write(*cp)
+}
+
+// WriteBuffers sends the concatenation of bufs as a single entry without
+// copying them into one buffer first, using a gathered sendmsg(2).
+func (j *journalWriter) WriteBuffers(bufs [][]byte) (int, error) {
+	return j.writeEntry(nil, bufs)
+}
+
+// writeEntry sends p, or the concatenation of bufs if bufs is not nil, as a
+// single entry.
+func (j *journalWriter) writeEntry(p []byte, bufs [][]byte) (n int, err error) {
+	send := func() (int, error) {
+		if bufs != nil {
+			return j.sendBuffers(bufs)
+		}
+		return j.send(p, nil)
+	}
+	// NOTE: No mutex needed. datagram socket writes are atomic
+	n, err = send()
+	for i, d := 0, j.backoff; i < j.retries && errors.Is(err, syscall.ENOBUFS); i++ {
+		time.Sleep(d)
+		d *= 2
+		n, err = send()
+	}
+	if err == nil {
+		return n, nil
+	}
+	// fail silently if the journal is not available, or if it is too busy
+	// to accept the entry without blocking
+	if errors.Is(err, syscall.ENOENT) || j.nonblock && errors.Is(err, syscall.EAGAIN) {
+		if j.stats != nil {
+			j.stats.dropped.Add(1)
+		}
+		return 0, nil
+	}
+
+	if !errors.Is(err, syscall.ENOBUFS) && !errors.Is(err, syscall.EMSGSIZE) {
+		return n, err
+	}
+
+	// Message does not fit in a single datagram, write to a temp file and send the file descriptor
+	if j.stats != nil {
+		j.stats.memfds.Add(1)
+	}
+	file, err := tempFd()
+	if err != nil {
+		return n, err
+	}
+	defer file.Close()
+	if bufs == nil {
+		bufs = [][]byte{p}
+	}
+	size := 0
+	for _, b := range bufs {
+		n, err := file.Write(b)
+		size += n
+		if err != nil {
+			return size, err
+		}
+	}
+	if err := trySeal(file); err != nil {
+		return size, err
+	}
+	fd := int(file.Fd())
+	if _, err := j.send(nil, syscall.UnixRights(fd)); err != nil {
+		if j.nonblock && errors.Is(err, syscall.EAGAIN) {
+			if j.stats != nil {
+				j.stats.dropped.Add(1)
+			}
+			return 0, nil
+		}
+		return 0, err
+	}
+	return size, nil
+}
+
+// sendBuffers sends the concatenation of bufs as a single datagram. Unless
+// nonblock is set, it waits until the socket has room for it.
+func (j *journalWriter) sendBuffers(bufs [][]byte) (int, error) {
+	rc, err := j.conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	flags := 0
+	if j.nonblock {
+		flags = unix.MSG_DONTWAIT
+	}
+	var n int
+	to := &unix.SockaddrUnix{Name: j.addr.Name}
+	werr := rc.Write(func(fd uintptr) bool {
+		n, err = unix.SendmsgBuffers(int(fd), bufs, nil, to, flags)
+		// Returning false waits for the socket to become writable.
+		return j.nonblock || !errors.Is(err, unix.EAGAIN)
+	})
+	if werr != nil {
+		return 0, werr
+	}
+	return n, err
+}
+
+// send sends p with the ancillary data oob as a single datagram. Unless
+// nonblock is set, it waits until the socket has room for it.
+func (j *journalWriter) send(p, oob []byte) (int, error) {
+	if !j.nonblock {
+		n, _, err := j.conn.WriteMsgUnix(p, oob, j.addr)
+		return n, err
+	}
+	rc, err := j.conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	to := &syscall.SockaddrUnix{Name: j.addr.Name}
+	werr := rc.Write(func(fd uintptr) bool {
+		n, err = syscall.SendmsgN(int(fd), p, oob, to, syscall.MSG_DONTWAIT)
+		return true
+	})
+	if werr != nil {
+		return 0, werr
+	}
+	return n, err
+}
@@ -0,0 +1,101 @@
+package journaltest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Fields is a set of field values an entry is expected to have.
+type Fields map[string]string
+
+// Matches reports whether e has every field in fields with the given value.
+// Repeated fields match if any of their values matches.
+func (e Entry) Matches(fields Fields) bool {
+	for k, want := range fields {
+		found := false
+		for _, v := range e.All(k) {
+			if v == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Has reports whether e has a field named key.
+func (e Entry) Has(key string) bool {
+	_, ok := e.Get(key)
+	return ok
+}
+
+// AssertLogged fails the test unless an entry matching fields is received
+// within five seconds. It returns the first matching entry.
+func (s *Server) AssertLogged(tb testing.TB, fields Fields) Entry {
+	tb.Helper()
+	deadline := time.NewTimer(5 * time.Second)
+	defer deadline.Stop()
+	for {
+		s.mu.Lock()
+		entries := s.entries
+		changed := s.changed
+		s.mu.Unlock()
+		for _, e := range entries {
+			if e.Matches(fields) {
+				return e
+			}
+		}
+		select {
+		case <-changed:
+		case <-deadline.C:
+			tb.Fatalf("journaltest: no entry matching %v among %d entries:\n%s", fields, len(entries), formatEntries(entries))
+			return nil
+		}
+	}
+}
+
+// AssertNotLogged fails the test if an entry matching fields was received.
+// It only checks the entries received so far.
+func (s *Server) AssertNotLogged(tb testing.TB, fields Fields) {
+	tb.Helper()
+	for _, e := range s.Entries() {
+		if e.Matches(fields) {
+			tb.Errorf("journaltest: unexpected entry matching %v:\n%s", fields, e)
+		}
+	}
+}
+
+// AssertHasFields fails the test unless e has a field for each of keys.
+func AssertHasFields(tb testing.TB, e Entry, keys ...string) {
+	tb.Helper()
+	for _, k := range keys {
+		if !e.Has(k) {
+			tb.Errorf("journaltest: entry has no field %s:\n%s", k, e)
+		}
+	}
+}
+
+// AssertNoFields fails the test if e has a field for any of keys.
+func AssertNoFields(tb testing.TB, e Entry, keys ...string) {
+	tb.Helper()
+	for _, k := range keys {
+		if e.Has(k) {
+			tb.Errorf("journaltest: entry has unexpected field %s:\n%s", k, e)
+		}
+	}
+}
+
+func formatEntries(entries []Entry) string {
+	var b strings.Builder
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteString("--\n")
+		}
+		b.WriteString(e.String())
+	}
+	return b.String()
+}
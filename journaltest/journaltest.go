@@ -0,0 +1,237 @@
+// Package journaltest provides a fake journald for testing programs that log
+// to the journal using the [native protocol].
+//
+// A [Server] listens on a temporary datagram socket, decodes every entry
+// sent to it, including entries passed as a memfd like real journald
+// accepts them, and keeps it for inspection:
+//
+//	srv := journaltest.NewServer(t)
+//	h, err := slogjournal.NewHandler(&slogjournal.Options{Addr: srv.Addr})
+//	...
+//	entries := srv.WaitEntries(t, 1)
+//
+// [native protocol]: https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+package journaltest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// Field is a single field of a journal entry.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Entry is a journal entry as received by the server. Fields are kept in the
+// order they were sent; a key may appear more than once.
+type Entry []Field
+
+// Get returns the value of the first field named key.
+func (e Entry) Get(key string) (string, bool) {
+	for _, f := range e {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// All returns the values of all fields named key.
+func (e Entry) All(key string) []string {
+	var vs []string
+	for _, f := range e {
+		if f.Key == key {
+			vs = append(vs, f.Value)
+		}
+	}
+	return vs
+}
+
+// Map returns the fields of e as a map. For repeated keys the last value
+// wins.
+func (e Entry) Map() map[string]string {
+	m := make(map[string]string, len(e))
+	for _, f := range e {
+		m[f.Key] = f.Value
+	}
+	return m
+}
+
+// String formats e with one KEY=VALUE pair per line.
+func (e Entry) String() string {
+	var b strings.Builder
+	for _, f := range e {
+		fmt.Fprintf(&b, "%s=%q\n", f.Key, f.Value)
+	}
+	return b.String()
+}
+
+// Decode parses a single journal entry in the native protocol format, see
+// [slogjournal.Decode].
+func Decode(b []byte) (Entry, error) {
+	fs, err := slogjournal.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	e := make(Entry, len(fs))
+	for i, f := range fs {
+		e[i] = Field{Key: f.Key, Value: string(f.Value)}
+	}
+	return e, nil
+}
+
+// Server is a fake journald listening on a temporary unix datagram socket.
+type Server struct {
+	// Addr is the address of the socket. Pass it as Options.Addr to the
+	// handler under test.
+	Addr string
+
+	conn *net.UnixConn
+	dir  string
+
+	mu      sync.Mutex
+	changed chan struct{}
+	entries []Entry
+	errs    []error
+}
+
+// NewServer starts a Server. It is stopped when the test finishes. Windows
+// has no datagram sockets, so there the test is skipped.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+	if runtime.GOOS == "windows" {
+		tb.Skip("journaltest: datagram sockets are not supported on windows")
+	}
+	dir, err := os.MkdirTemp("", "journaltest")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	s, err := listen(filepath.Join(dir, "socket"))
+	if err != nil {
+		os.RemoveAll(dir)
+		tb.Fatal(err)
+	}
+	s.dir = dir
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// NewAbstractServer starts a Server listening on a socket in the abstract
+// namespace, for environments without a writable temporary directory. Its
+// Addr starts with '@'. Abstract sockets are only supported on Linux. The
+// server is stopped when the test finishes.
+func NewAbstractServer(tb testing.TB) *Server {
+	tb.Helper()
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		tb.Fatal(err)
+	}
+	s, err := listen("@journaltest-" + hex.EncodeToString(id[:]))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// listen starts a Server on the socket at addr.
+func listen(addr string) (*Server, error) {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Addr:    addr,
+		conn:    conn,
+		changed: make(chan struct{}),
+	}
+	go s.serve()
+	return s, nil
+}
+
+// Close stops the server and removes its socket.
+func (s *Server) Close() {
+	s.conn.Close()
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+}
+
+func (s *Server) add(e Entry, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.errs = append(s.errs, err)
+	} else {
+		s.entries = append(s.entries, e)
+	}
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Entries returns the entries received so far.
+func (s *Server) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry(nil), s.entries...)
+}
+
+// Errors returns the errors encountered decoding malformed entries.
+func (s *Server) Errors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.errs...)
+}
+
+// Reset forgets all entries and errors received so far.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+	s.errs = nil
+}
+
+// Wait waits until at least n entries were received or the timeout expires,
+// and returns the entries received so far.
+func (s *Server) Wait(n int, timeout time.Duration) ([]Entry, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		s.mu.Lock()
+		entries := append([]Entry(nil), s.entries...)
+		changed := s.changed
+		s.mu.Unlock()
+		if len(entries) >= n {
+			return entries, nil
+		}
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return entries, fmt.Errorf("journaltest: received %d entries, want %d", len(entries), n)
+		}
+	}
+}
+
+// WaitEntries is like Wait with a timeout of five seconds, failing the test
+// if fewer than n entries arrive.
+func (s *Server) WaitEntries(tb testing.TB, n int) []Entry {
+	tb.Helper()
+	entries, err := s.Wait(n, 5*time.Second)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return entries
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package journaltest
+
+// serve receives plain datagrams, as file descriptors cannot be passed
+// over sockets on this platform.
+func (s *Server) serve() {
+	buf := make([]byte, 4<<20)
+	for {
+		n, _, err := s.conn.ReadFromUnix(buf)
+		if err != nil {
+			return
+		}
+		s.add(Decode(buf[:n]))
+	}
+}
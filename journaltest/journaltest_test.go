@@ -0,0 +1,115 @@
+package journaltest_test
+
+import (
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+
+	slogjournal "github.com/systemd/slog-journal"
+	"github.com/systemd/slog-journal/journaltest"
+)
+
+func TestServer(t *testing.T) {
+	srv := journaltest.NewServer(t)
+	h, err := slogjournal.NewHandler(&slogjournal.Options{Addr: srv.Addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := slog.New(h)
+	log.Info("Hello, World!", "KEY", "value")
+	log.Warn("multi\nline", "KEY", "a", "KEY", "b")
+
+	entries := srv.WaitEntries(t, 2)
+	if msg, _ := entries[0].Get("MESSAGE"); msg != "Hello, World!" {
+		t.Errorf("unexpected entry:\n%s", entries[0])
+	}
+	if v := entries[0].Map()["KEY"]; v != "value" {
+		t.Errorf("unexpected entry:\n%s", entries[0])
+	}
+	if msg, _ := entries[1].Get("MESSAGE"); msg != "multi\nline" {
+		t.Errorf("unexpected entry:\n%s", entries[1])
+	}
+	if keys := entries[1].All("KEY"); len(keys) != 2 {
+		t.Errorf("unexpected entry:\n%s", entries[1])
+	}
+	if errs := srv.Errors(); len(errs) != 0 {
+		t.Error(errs)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	if _, err := journaltest.Decode([]byte("KEY=value")); err == nil {
+		t.Error("expected error for unterminated field")
+	}
+	if _, err := journaltest.Decode([]byte("KEY\n\x05\x00\x00\x00\x00\x00\x00\x00abc")); err == nil {
+		t.Error("expected error for truncated binary field")
+	}
+	e, err := journaltest.Decode([]byte("A=1\nB\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := e.Get("B"); v != "a\nb" {
+		t.Errorf("unexpected entry:\n%s", e)
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	srv := journaltest.NewServer(t)
+	h, err := slogjournal.NewHandler(&slogjournal.Options{Addr: srv.Addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.New(h).Error("failed", "USER_ID", "42")
+
+	e := srv.AssertLogged(t, journaltest.Fields{"MESSAGE": "failed", "PRIORITY": "3"})
+	journaltest.AssertHasFields(t, e, "USER_ID", "SYSLOG_IDENTIFIER")
+	journaltest.AssertNoFields(t, e, "TRACE_ID")
+	srv.AssertNotLogged(t, journaltest.Fields{"MESSAGE": "succeeded"})
+
+	if e.Matches(journaltest.Fields{"USER_ID": "43"}) {
+		t.Error("expected mismatch")
+	}
+}
+
+func TestServerMemfd(t *testing.T) {
+	srv := journaltest.NewServer(t)
+	h, err := slogjournal.NewHandler(&slogjournal.Options{Addr: srv.Addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Larger than any datagram the socket buffers allow, so the handler has
+	// to fall back to a memfd.
+	large := strings.Repeat("a", 16<<20)
+	slog.New(h).Info("large", "PAYLOAD", large)
+
+	e := srv.AssertLogged(t, journaltest.Fields{"MESSAGE": "large"})
+	if v, _ := e.Get("PAYLOAD"); v != large {
+		t.Errorf("payload has %d bytes, want %d", len(v), len(large))
+	}
+	if errs := srv.Errors(); len(errs) != 0 {
+		t.Error(errs)
+	}
+}
+
+func TestAbstractServer(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("abstract sockets are only supported on Linux")
+	}
+	srv := journaltest.NewAbstractServer(t)
+	if !strings.HasPrefix(srv.Addr, "@") {
+		t.Fatalf("unexpected address %q", srv.Addr)
+	}
+	for _, addr := range []string{srv.Addr, "\x00" + srv.Addr[1:]} {
+		h, err := slogjournal.NewHandler(&slogjournal.Options{Addr: addr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		slog.New(h).Info("abstract")
+	}
+	for _, e := range srv.WaitEntries(t, 2) {
+		if msg, _ := e.Get("MESSAGE"); msg != "abstract" {
+			t.Errorf("unexpected entry:\n%s", e)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+//go:build unix
+
+package journaltest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"syscall"
+)
+
+// serve receives entries until the socket is closed, reading those passed
+// as a file descriptor from the memfd.
+func (s *Server) serve() {
+	buf := make([]byte, 4<<20)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	for {
+		n, oobn, flags, _, err := s.conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			return
+		}
+		switch {
+		case flags&syscall.MSG_TRUNC != 0:
+			s.add(nil, errors.New("journaltest: datagram truncated"))
+		case oobn > 0:
+			s.add(readFd(oob[:oobn], n))
+		default:
+			s.add(Decode(buf[:n]))
+		}
+	}
+}
+
+// readFd decodes an entry passed as a file descriptor, like journald does
+// for entries too large for a single datagram. Such datagrams carry no data
+// of their own.
+func readFd(oob []byte, n int) (Entry, error) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, err
+	}
+	var fds []int
+	for _, m := range msgs {
+		rights, err := syscall.ParseUnixRights(&m)
+		if err != nil {
+			return nil, err
+		}
+		fds = append(fds, rights...)
+	}
+	files := make([]*os.File, len(fds))
+	for i, fd := range fds {
+		files[i] = os.NewFile(uintptr(fd), "journal")
+		defer files[i].Close()
+	}
+	if len(files) != 1 {
+		return nil, fmt.Errorf("journaltest: expected a single file descriptor, got %d", len(files))
+	}
+	if n != 0 {
+		return nil, errors.New("journaltest: datagram with file descriptor carries data")
+	}
+	b, err := io.ReadAll(io.NewSectionReader(files[0], 0, math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	return Decode(b)
+}
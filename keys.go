@@ -0,0 +1,260 @@
+package slogjournal
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+)
+
+// MaxKeyLength is the longest field name journald accepts.
+const MaxKeyLength = 64
+
+// LongKeyPolicy controls how field names longer than [MaxKeyLength] are
+// handled.
+type LongKeyPolicy int
+
+const (
+	// LongKeyDrop drops fields whose name is too long.
+	LongKeyDrop LongKeyPolicy = iota
+	// LongKeyTruncate cuts the name down to MaxKeyLength characters. Distinct
+	// names sharing a long prefix end up with the same key.
+	LongKeyTruncate
+	// LongKeyHash cuts the name down and replaces its tail with a hash of the
+	// full name, so distinct names stay distinct.
+	LongKeyHash
+)
+
+// InvalidKeyPolicy controls how field names that journald would reject are
+// handled.
+type InvalidKeyPolicy int
+
+const (
+	// InvalidKeyKeep sends the field as is; journald drops it.
+	InvalidKeyKeep InvalidKeyPolicy = iota
+	// InvalidKeyPrefix rewrites the name with [SanitizeKey] and prepends
+	// X_, so that "request.id" is sent as X_REQUEST_ID and the data is kept.
+	// Fields whose name is empty after sanitizing are dropped.
+	InvalidKeyPrefix
+)
+
+// UnderscoreKeyPolicy controls how field names starting with an underscore
+// are handled.
+type UnderscoreKeyPolicy int
+
+const (
+	// UnderscoreKeyKeep leaves the name alone; it is handled according to
+	// Options.InvalidKeys.
+	UnderscoreKeyKeep UnderscoreKeyPolicy = iota
+	// UnderscoreKeyStrip removes the leading underscores, so _PID becomes
+	// PID. Names consisting only of underscores are dropped.
+	UnderscoreKeyStrip
+	// UnderscoreKeyPrefix prepends a U, so _PID becomes U_PID.
+	UnderscoreKeyPrefix
+)
+
+// renameUnderscoreKey applies p to the key k starting with an underscore.
+func renameUnderscoreKey(k string, p UnderscoreKeyPolicy) string {
+	if p == UnderscoreKeyStrip {
+		return strings.TrimLeft(k, "_")
+	}
+	return "U" + k
+}
+
+// shortenKey applies p to the over-long key k. It reports false if the field
+// should be dropped.
+func shortenKey(k string, p LongKeyPolicy) (string, bool) {
+	switch p {
+	case LongKeyTruncate:
+		return k[:MaxKeyLength], true
+	case LongKeyHash:
+		sum := fnv.New32a()
+		sum.Write([]byte(k))
+		suffix := fmt.Sprintf("_%08X", sum.Sum32())
+		return k[:MaxKeyLength-len(suffix)] + suffix, true
+	default:
+		return "", false
+	}
+}
+
+// SanitizeKey turns k into a valid journal field name: it upper-cases k,
+// maps '.', '-' and spaces to underscores, strips all other characters
+// outside [A-Z0-9_] and then any leading digits and underscores. It returns
+// the empty string if nothing is left. It is meant for use in
+// Options.ReplaceAttr:
+//
+//	ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+//		a.Key = slogjournal.SanitizeKey(a.Key)
+//		return a
+//	},
+//
+// so that "request.id" is logged as REQUEST_ID instead of being dropped.
+func SanitizeKey(k string) string {
+	b := make([]byte, 0, len(k))
+	for _, c := range []byte(strings.ToUpper(k)) {
+		switch {
+		case c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_':
+			b = append(b, c)
+		case c == '.' || c == '-' || c == ' ':
+			b = append(b, '_')
+		}
+	}
+	for len(b) > 0 && (b[0] == '_' || b[0] >= '0' && b[0] <= '9') {
+		b = b[1:]
+	}
+	return string(b)
+}
+
+// validKey reports whether journald accepts k from a client, apart from its
+// length: it must match ^[A-Z_][A-Z0-9_]*$ and must not start with an
+// underscore, which marks trusted fields.
+func validKey(k string) bool {
+	if k == "" || k[0] == '_' || k[0] >= '0' && k[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// warnInvalidKey reports k to Options.WarnInvalidKeys.
+func (h *Handler) warnInvalidKey(k string) {
+	if h.opts.WarnInvalidKeys != nil {
+		h.opts.WarnInvalidKeys(k)
+	}
+}
+
+// keyInfo is the result of applying the key policies to a field name.
+type keyInfo struct {
+	// key is the name sent to the journal, or empty if the field is dropped.
+	key string
+	// eq is key followed by '=', the framing of the common case.
+	eq []byte
+	// renamed is the original name if it was renamed for starting with an
+	// underscore, see Options.UnderscoreKeys.
+	renamed string
+	// invalid reports whether journald would reject the original name.
+	invalid bool
+}
+
+func newKeyInfo(k string) *keyInfo {
+	return &keyInfo{key: k, eq: append([]byte(k), '=')}
+}
+
+// Builtin fields are always valid, so their framing is computed once.
+var (
+	keyMessage            = newKeyInfo("MESSAGE")
+	keyPriority           = newKeyInfo("PRIORITY")
+	keyLevel              = newKeyInfo("LEVEL")
+	keyVerbosity          = newKeyInfo("VERBOSITY")
+	keyCodeFile           = newKeyInfo("CODE_FILE")
+	keyCodeFunc           = newKeyInfo("CODE_FUNC")
+	keyCodeLine           = newKeyInfo("CODE_LINE")
+	keySyslogTimestamp    = newKeyInfo("SYSLOG_TIMESTAMP")
+	keySyslogIdentifier   = newKeyInfo("SYSLOG_IDENTIFIER")
+	keyMonotonicTimestamp = newKeyInfo("MONOTONIC_TIMESTAMP")
+	keyName               = newKeyInfo(NameKey)
+	keyDocumentation      = newKeyInfo(DocumentationKey)
+	keyTraceID            = newKeyInfo("TRACE_ID")
+	keySpanID             = newKeyInfo("SPAN_ID")
+	keyTraceFlags         = newKeyInfo("TRACE_FLAGS")
+	keyRecordJSON         = newKeyInfo(RecordJSONKey)
+)
+
+// maxCachedKeys bounds the number of field names a keyCache remembers, so
+// that programs using unbounded sets of keys do not leak memory.
+const maxCachedKeys = 1024
+
+// keyCache interns the keyInfo of frequently used field names. It is shared
+// by a handler and all handlers derived from it, which have the same key
+// policies. A plain map is used rather than a sync.Map so that names built
+// in a byte slice can be looked up without allocating.
+type keyCache struct {
+	mu sync.RWMutex
+	m  map[string]*keyInfo
+}
+
+// keyInfo returns the result of applying the key policies to k.
+func (h *Handler) keyInfo(k string) *keyInfo {
+	if h.keys != nil {
+		h.keys.mu.RLock()
+		ki := h.keys.m[k]
+		h.keys.mu.RUnlock()
+		if ki != nil {
+			return ki
+		}
+	}
+	return h.cacheKeyInfo(k)
+}
+
+// keyInfoBytes is like keyInfo for a name held in a byte slice.
+func (h *Handler) keyInfoBytes(k []byte) *keyInfo {
+	if h.keys != nil {
+		h.keys.mu.RLock()
+		ki := h.keys.m[string(k)]
+		h.keys.mu.RUnlock()
+		if ki != nil {
+			return ki
+		}
+	}
+	return h.cacheKeyInfo(string(k))
+}
+
+// cacheKeyInfo computes the keyInfo of k and remembers it if there is room.
+func (h *Handler) cacheKeyInfo(k string) *keyInfo {
+	ki := h.computeKeyInfo(k)
+	if h.keys != nil {
+		h.keys.mu.Lock()
+		if h.keys.m == nil {
+			h.keys.m = make(map[string]*keyInfo)
+		}
+		if len(h.keys.m) < maxCachedKeys {
+			h.keys.m[k] = ki
+		}
+		h.keys.mu.Unlock()
+	}
+	return ki
+}
+
+func (h *Handler) computeKeyInfo(k string) *keyInfo {
+	ki := &keyInfo{invalid: !validKey(k)}
+	if strings.HasPrefix(k, "_") && h.opts.UnderscoreKeys != UnderscoreKeyKeep {
+		ki.renamed = k
+		if k = renameUnderscoreKey(k, h.opts.UnderscoreKeys); k == "" {
+			return ki
+		}
+	}
+	if !validKey(k) && h.opts.InvalidKeys == InvalidKeyPrefix {
+		if k = SanitizeKey(k); k == "" {
+			return ki
+		}
+		k = "X_" + k
+	}
+	if len(k) > MaxKeyLength {
+		short, ok := shortenKey(k, h.opts.LongKeys)
+		if !ok {
+			ki.invalid = true
+			return ki
+		}
+		k = short
+	}
+	ki.key = k
+	ki.eq = append([]byte(k), '=')
+	return ki
+}
+
+// newFieldMap returns the keyInfo of the field names of Options.FieldMap.
+func newFieldMap(m map[string]string) map[string]*keyInfo {
+	if len(m) == 0 {
+		return nil
+	}
+	fm := make(map[string]*keyInfo, len(m))
+	for k, name := range m {
+		fm[k] = newKeyInfo(name)
+	}
+	return fm
+}
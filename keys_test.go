@@ -0,0 +1,140 @@
+package slogjournal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeKey(t *testing.T) {
+	for k, want := range map[string]string{
+		"request.id":    "REQUEST_ID",
+		"user-agent":    "USER_AGENT",
+		"http status":   "HTTP_STATUS",
+		"VALID_KEY":     "VALID_KEY",
+		"_private":      "PRIVATE",
+		"2fa_enabled":   "FA_ENABLED",
+		"weird!@#chars": "WEIRDCHARS",
+		"ünïcode":       "NCODE",
+		"__":            "",
+		"":              "",
+	} {
+		if got := SanitizeKey(k); got != want {
+			t.Errorf("SanitizeKey(%q) = %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestWarnInvalidKeys(t *testing.T) {
+	var keys []string
+	h, err := NewHandler(&Options{WarnInvalidKeys: func(k string) { keys = append(keys, k) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = io.Discard
+	long := strings.Repeat("A", MaxKeyLength+1)
+	slog.New(h).With("request.id", 1).Info("hello", "VALID", 1, "_TRUSTED", 2, long, 3, slog.Group("G", "Y", 4), slog.Group("g", "Z", 5))
+	want := []string{"request.id", "_TRUSTED", long, "g_Z"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("got invalid keys %q, want %q", keys, want)
+	}
+}
+
+func TestInvalidKeyPrefix(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{InvalidKeys: InvalidKeyPrefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	slog.New(h).Info("hello", "request.id", "42", "VALID", "1", "!!", "lost")
+	m, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["X_REQUEST_ID"] != "42" || m["VALID"] != "1" {
+		t.Errorf("unexpected fields %v", m)
+	}
+	if _, ok := m["request.id"]; ok {
+		t.Error("invalid key was sent")
+	}
+	for k := range m {
+		if !validKey(k) {
+			t.Errorf("invalid key %q was sent", k)
+		}
+	}
+}
+
+func TestUnderscoreKeys(t *testing.T) {
+	for _, tt := range []struct {
+		policy UnderscoreKeyPolicy
+		key    string
+	}{
+		{UnderscoreKeyStrip, "TRUSTED"},
+		{UnderscoreKeyPrefix, "U_TRUSTED"},
+	} {
+		buf := new(bytes.Buffer)
+		h, err := NewHandler(&Options{UnderscoreKeys: tt.policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.w = buf
+		slog.New(h).Info("hello", "_TRUSTED", "1")
+		m, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m[tt.key] != "1" || m["RENAMED"] != "_TRUSTED" {
+			t.Errorf("policy %d: unexpected fields %v", tt.policy, m)
+		}
+	}
+}
+
+func TestKeyCache(t *testing.T) {
+	var warned int
+	h, err := NewHandler(&Options{WarnInvalidKeys: func(string) { warned++ }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	h.w = buf
+	logger := slog.New(h)
+	logger.Info("first", "bad.key", 1)
+	logger.Info("second", "bad.key", 2)
+	if warned != 2 {
+		t.Errorf("warned %d times, want 2", warned)
+	}
+	for i := range maxCachedKeys + 10 {
+		h.keyInfo(fmt.Sprintf("KEY_%d", i))
+	}
+	if n := len(h.keys.m); n != maxCachedKeys {
+		t.Errorf("cached %d keys, want %d", n, maxCachedKeys)
+	}
+	if got := string(h.appendKV(nil, "KEY_5000", []byte("x"))); got != "KEY_5000=x\n" {
+		t.Errorf("unexpected encoding %q", got)
+	}
+}
+
+func TestFieldMap(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{
+		FieldMap:    map[string]string{"trace_id": "TRACE_ID", "msg_id": "MESSAGE_ID"},
+		InvalidKeys: InvalidKeyPrefix,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	slog.New(h).WithGroup("http").Info("hello", "trace_id", "abc", slog.Group("inner", "msg_id", "42"), "other", "x")
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["TRACE_ID"] != "abc" || kv["MESSAGE_ID"] != "42" || kv["X_HTTP_OTHER"] != "x" {
+		t.Errorf("unexpected fields %v", kv)
+	}
+}
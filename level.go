@@ -0,0 +1,107 @@
+package slogjournal
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// priorityLevels maps each syslog priority to the level of the same name.
+var priorityLevels = [...]slog.Level{
+	LevelEmergency,
+	LevelAlert,
+	LevelCritical,
+	slog.LevelError,
+	slog.LevelWarn,
+	LevelNotice,
+	slog.LevelInfo,
+	slog.LevelDebug,
+}
+
+// ParseLevel parses a systemd or syslog level keyword, as accepted by
+// SYSTEMD_LOG_LEVEL or systemctl, into the corresponding level. The keywords
+// are "debug", "info", "notice", "warning", "err", "crit", "alert" and
+// "emerg". The longer forms "warn", "error", "critical" and "emergency" and
+// the numeric priorities 0 to 7 are accepted too. Matching is
+// case-insensitive.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "notice":
+		return LevelNotice, nil
+	case "warning", "warn":
+		return slog.LevelWarn, nil
+	case "err", "error":
+		return slog.LevelError, nil
+	case "crit", "critical":
+		return LevelCritical, nil
+	case "alert":
+		return LevelAlert, nil
+	case "emerg", "emergency":
+		return LevelEmergency, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && n >= 0 && n < len(priorityLevels) {
+		return priorityLevels[n], nil
+	}
+	return 0, fmt.Errorf("slogjournal: unknown log level %q", s)
+}
+
+// Level is a [slog.Level] that knows the names of the extra levels defined by
+// this package. It renders LevelNotice, LevelCritical, LevelAlert and
+// LevelEmergency as NOTICE, CRITICAL, ALERT and EMERGENCY instead of
+// "INFO+1" or "ERROR+1", and parses them back.
+type Level slog.Level
+
+// Level returns l as a [slog.Level], so Level implements [slog.Leveler].
+func (l Level) Level() slog.Level {
+	return slog.Level(l)
+}
+
+// String returns the name of l. Levels that are neither a slog level nor one
+// of this package's levels are formatted like [slog.Level.String].
+func (l Level) String() string {
+	return levelName(slog.Level(l))
+}
+
+// MarshalText implements [encoding.TextMarshaler] using [Level.String].
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler]. It accepts everything
+// [ParseLevel] accepts as well as the forms produced by
+// [slog.Level.MarshalText], such as "INFO+2".
+func (l *Level) UnmarshalText(data []byte) error {
+	if v, err := ParseLevel(string(data)); err == nil {
+		*l = Level(v)
+		return nil
+	}
+	var v slog.Level
+	if err := v.UnmarshalText(data); err != nil {
+		return err
+	}
+	*l = Level(v)
+	return nil
+}
+
+// ReplaceLevelName can be used as, or called from, the ReplaceAttr function of
+// other handlers such as [slog.TextHandler] so that they print this package's
+// levels by name.
+func ReplaceLevelName(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.LevelKey {
+		if l, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(levelName(l))
+		}
+	}
+	return a
+}
+
+// PriorityToLevel is the inverse of [LevelToPriority]. It returns the level
+// whose name matches the severity of p. Facility bits in p are ignored.
+func PriorityToLevel(p Priority) slog.Level {
+	return priorityLevels[p&0x07]
+}
@@ -0,0 +1,103 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"info", slog.LevelInfo},
+		{"notice", LevelNotice},
+		{"warning", slog.LevelWarn},
+		{"WARN", slog.LevelWarn},
+		{"err", slog.LevelError},
+		{"crit", LevelCritical},
+		{"alert", LevelAlert},
+		{"emerg", LevelEmergency},
+		{" Emergency ", LevelEmergency},
+		{"3", slog.LevelError},
+		{"7", slog.LevelDebug},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if err != nil {
+			t.Errorf("ParseLevel(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "verbose", "8", "-1"} {
+		if _, err := ParseLevel(in); err == nil {
+			t.Errorf("ParseLevel(%q): expected error", in)
+		}
+	}
+}
+
+func TestLevelText(t *testing.T) {
+	for _, l := range []slog.Level{slog.LevelDebug, slog.LevelInfo, LevelNotice, slog.LevelWarn, slog.LevelError, LevelCritical, LevelAlert, LevelEmergency, slog.LevelWarn + 1} {
+		b, err := Level(l).MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Level
+		if err := got.UnmarshalText(b); err != nil {
+			t.Fatalf("UnmarshalText(%q): %v", b, err)
+		}
+		if got.Level() != l {
+			t.Errorf("round trip of %v through %q gave %v", l, b, got.Level())
+		}
+	}
+	if s := Level(LevelCritical).String(); s != "CRITICAL" {
+		t.Errorf("got %q, want CRITICAL", s)
+	}
+}
+
+func TestReplaceLevelName(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: ReplaceLevelName}))
+	log.Log(context.TODO(), LevelNotice, "hello")
+	if !strings.Contains(buf.String(), "level=NOTICE") {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestLevelToPriorityFacility(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{Level: slog.LevelInfo, LevelToPriority: func(slog.Level) Priority {
+		return 16<<3 | logErr // LOG_LOCAL0 | LOG_ERR
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	slog.New(h).Info("facility")
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["PRIORITY"] != "3" {
+		t.Errorf("PRIORITY = %q, want 3", kv["PRIORITY"])
+	}
+}
+
+func TestPriorityToLevel(t *testing.T) {
+	for p := logEmerg; p <= logDebug; p++ {
+		if got := LevelToPriority(PriorityToLevel(p)); got != p {
+			t.Errorf("priority %d round-tripped to %d", p, got)
+		}
+	}
+	// 3<<3 is the daemon facility.
+	if l := PriorityToLevel(3<<3 | logErr); l != slog.LevelError {
+		t.Errorf("expected facility bits to be ignored, got %v", l)
+	}
+}
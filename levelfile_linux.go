@@ -0,0 +1,84 @@
+//go:build linux
+
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"golang.org/x/sys/unix"
+)
+
+// FileLevel is a [slog.Leveler] whose level is read from a file, such as
+// /run/myapp/log-level or a mounted ConfigMap, and updated whenever that file
+// changes. The file holds a single level as accepted by [ParseLevel].
+// This allows debug logging to be toggled without restarting the program.
+type FileLevel struct {
+	path     string
+	fallback slog.Level
+	level    atomic.Int64
+	inotify  *os.File
+}
+
+// WatchLevelFile returns a FileLevel that follows the level stored in path.
+// While the file is missing or does not hold a valid level, fallback is
+// used. The directory containing path must exist. Call Close to stop
+// watching.
+func WatchLevelFile(path string, fallback slog.Level) (*FileLevel, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	// The directory is watched rather than the file itself, so that files
+	// replaced by rename and ConfigMap symlink swaps are noticed too.
+	mask := uint32(unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_TO | unix.IN_MOVED_FROM)
+	if _, err := unix.InotifyAddWatch(fd, filepath.Dir(path), mask); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	v := &FileLevel{
+		path:     path,
+		fallback: fallback,
+		inotify:  os.NewFile(uintptr(fd), "inotify"),
+	}
+	v.reload()
+	go v.watch()
+	return v, nil
+}
+
+// Level returns the level currently stored in the file.
+func (v *FileLevel) Level() slog.Level {
+	return slog.Level(v.level.Load())
+}
+
+// Close stops watching the file. The level no longer changes afterwards.
+func (v *FileLevel) Close() error {
+	return v.inotify.Close()
+}
+
+func (v *FileLevel) watch() {
+	buf := make([]byte, 4096)
+	for {
+		// The events themselves are not interesting, any change in the
+		// directory causes the file to be read again.
+		if _, err := v.inotify.Read(buf); err != nil {
+			return
+		}
+		v.reload()
+	}
+}
+
+func (v *FileLevel) reload() {
+	level := v.fallback
+	if b, err := os.ReadFile(v.path); err == nil {
+		if l, err := ParseLevel(string(bytes.TrimSpace(b))); err == nil {
+			level = l
+		}
+	}
+	v.level.Store(int64(level))
+}
+
+var _ slog.Leveler = &FileLevel{}
@@ -0,0 +1,54 @@
+//go:build linux
+
+package slogjournal
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchLevelFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log-level")
+	v, err := WatchLevelFile(path, slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+
+	if v.Level() != slog.LevelInfo {
+		t.Errorf("expected fallback level, got %v", v.Level())
+	}
+
+	waitFor := func(want slog.Level) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for v.Level() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("level is %v, want %v", v.Level(), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("debug\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(slog.LevelDebug)
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte("warning"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(slog.LevelWarn)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(slog.LevelInfo)
+}
@@ -0,0 +1,13 @@
+module github.com/systemd/slog-journal/logrjournal
+
+go 1.22.1
+
+require (
+	github.com/go-logr/logr v1.4.4
+	github.com/systemd/slog-journal v0.0.0
+)
+
+require golang.org/x/sys v0.29.0 // indirect
+
+// The handler is developed in the same repository.
+replace github.com/systemd/slog-journal => ../
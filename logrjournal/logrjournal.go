@@ -0,0 +1,106 @@
+// Package logrjournal provides a [logr.LogSink] that writes to the systemd
+// journal, for programs built on logr such as controller-runtime. It is a
+// module of its own, so that only programs using it depend on logr.
+package logrjournal
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"github.com/go-logr/logr"
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// New returns a logr.Logger that writes to h.
+//
+// logr verbosity levels are mapped to slog levels the way logr itself does:
+// V(n) logs at slog.Level(-n). Set Options.Verbosity on h so that such
+// records get priority LOG_DEBUG and a VERBOSITY field. Logger names are
+// joined by dots and sent as the NAME field, see [slogjournal.Handler.WithName].
+// Keys are passed through [slogjournal.SanitizeKey], so that the usual
+// camelCase logr keys such as "requestID" are sent as REQUESTID rather than
+// being rejected by journald.
+func New(h *slogjournal.Handler) logr.Logger {
+	return logr.New(NewSink(h))
+}
+
+// NewSink returns a logr.LogSink that writes to h. See [New].
+func NewSink(h *slogjournal.Handler) logr.LogSink {
+	return &sink{h: h}
+}
+
+type sink struct {
+	h     *slogjournal.Handler
+	depth int
+}
+
+func (s *sink) Init(info logr.RuntimeInfo) {
+	s.depth += info.CallDepth
+}
+
+func (s *sink) Enabled(level int) bool {
+	return s.h.Enabled(context.Background(), slog.Level(-level))
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...any) {
+	s.log(slog.Level(-level), msg, keysAndValues)
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...any) {
+	if err != nil {
+		keysAndValues = append([]any{"ERROR", err.Error()}, keysAndValues...)
+	}
+	s.log(slog.LevelError, msg, keysAndValues)
+}
+
+func (s *sink) log(level slog.Level, msg string, keysAndValues []any) {
+	// Skip runtime.Callers, log and Info or Error. The frames of logr itself
+	// are accounted for in s.depth.
+	var pcs [1]uintptr
+	runtime.Callers(3+s.depth, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.AddAttrs(attrs(keysAndValues)...)
+	_ = s.h.Handle(context.Background(), r)
+}
+
+func (s *sink) WithValues(keysAndValues ...any) logr.LogSink {
+	s2 := *s
+	s2.h = s.h.WithAttrs(attrs(keysAndValues)).(*slogjournal.Handler)
+	return &s2
+}
+
+// attrs turns logr key-value pairs into attributes with sanitized keys.
+// Keys that sanitize to nothing are kept, and handled according to
+// Options.InvalidKeys.
+func attrs(keysAndValues []any) []slog.Attr {
+	var r slog.Record
+	r.Add(keysAndValues...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		if k := slogjournal.SanitizeKey(a.Key); k != "" {
+			a.Key = k
+		}
+		attrs = append(attrs, a)
+		return true
+	})
+	return attrs
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	s2 := *s
+	s2.h = s.h.WithName(name)
+	return &s2
+}
+
+func (s *sink) WithCallDepth(depth int) logr.LogSink {
+	s2 := *s
+	s2.depth += depth
+	return &s2
+}
+
+var (
+	_ logr.LogSink          = &sink{}
+	_ logr.CallDepthLogSink = &sink{}
+)
@@ -0,0 +1,54 @@
+package logrjournal
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	slogjournal "github.com/systemd/slog-journal"
+	"github.com/systemd/slog-journal/journaltest"
+)
+
+func TestLogger(t *testing.T) {
+	srv := journaltest.NewServer(t)
+	h, err := slogjournal.NewHandler(&slogjournal.Options{Addr: srv.Addr, Level: slog.Level(-2), Verbosity: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := New(h).WithName("controller").WithValues("reconciler", "test")
+
+	if !log.V(2).Enabled() {
+		t.Error("expected V(2) to be enabled")
+	}
+	if log.V(3).Enabled() {
+		t.Error("expected V(3) to be disabled")
+	}
+
+	log.V(1).Info("info", "request.id", "42")
+	log.Error(errors.New("boom"), "failed")
+
+	srv.WaitEntries(t, 2)
+	e := srv.AssertLogged(t, journaltest.Fields{
+		"MESSAGE":    "info",
+		"NAME":       "controller",
+		"VERBOSITY":  "1",
+		"PRIORITY":   "7",
+		"RECONCILER": "test",
+		"REQUEST_ID": "42",
+	})
+	if file, _ := e.Get("CODE_FILE"); !strings.HasSuffix(file, "logrjournal_test.go") {
+		t.Errorf("expected the caller as CODE_FILE, got %q", file)
+	}
+	journaltest.AssertHasFields(t, e, "CODE_LINE", "CODE_FUNC")
+
+	e = srv.AssertLogged(t, journaltest.Fields{
+		"MESSAGE":  "failed",
+		"NAME":     "controller",
+		"ERROR":    "boom",
+		"PRIORITY": "3",
+	})
+	if fn, _ := e.Get("CODE_FUNC"); !strings.HasSuffix(fn, "TestLogger") {
+		t.Errorf("expected the caller as CODE_FUNC, got %q", fn)
+	}
+}
@@ -0,0 +1,39 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"reflect"
+	"slices"
+)
+
+// mapAttrs returns the entries of v, if Options.ExpandMaps is set and v is a
+// map with string keys, as attributes sorted by key. Keys are rewritten with
+// SanitizeKey; entries whose key is empty afterwards are left out.
+func (h *Handler) mapAttrs(v any) ([]slog.Attr, bool) {
+	if !h.opts.ExpandMaps {
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	attrs := make([]slog.Attr, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		k := SanitizeKey(iter.Key().String())
+		if k == "" {
+			continue
+		}
+		attrs = append(attrs, slog.Any(k, iter.Value().Interface()))
+	}
+	slices.SortFunc(attrs, func(a, b slog.Attr) int {
+		switch {
+		case a.Key < b.Key:
+			return -1
+		case a.Key > b.Key:
+			return 1
+		}
+		return 0
+	})
+	return attrs, true
+}
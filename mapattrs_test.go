@@ -0,0 +1,43 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestExpandMaps(t *testing.T) {
+	labels := map[string]string{"app": "web", "k8s.io/zone": "eu-1", "!": "dropped"}
+	extra := map[string]any{"count": 3, "nested": map[string]int{"x": 1}}
+	for _, expand := range []bool{false, true} {
+		buf := new(bytes.Buffer)
+		h, err := NewHandler(&Options{Level: slog.LevelInfo, ExpandMaps: expand})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.w = buf
+		slog.New(h).Info("hello", "LABELS", labels, "EXTRA", extra, "IDS", map[int]string{1: "a"})
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{
+			"LABELS_APP":        "web",
+			"LABELS_K8S_IOZONE": "eu-1",
+			"EXTRA_COUNT":       "3",
+			"EXTRA_NESTED_X":    "1",
+			"IDS":               "map[1:a]",
+		}
+		if !expand {
+			want = map[string]string{"LABELS": slog.AnyValue(labels).String(), "IDS": "map[1:a]"}
+		}
+		for k, v := range want {
+			if kv[k] != v {
+				t.Errorf("ExpandMaps %v: %s=%q, want %q", expand, k, kv[k], v)
+			}
+		}
+		if expand && len(kv) != len(want)+7 {
+			t.Errorf("ExpandMaps %v: unexpected fields in %v", expand, kv)
+		}
+	}
+}
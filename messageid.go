@@ -0,0 +1,123 @@
+package slogjournal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// MessageID is a 128-bit identifier of an event type, sent as the
+// MESSAGE_ID field. journald expects it formatted as 32 lowercase
+// hexadecimal digits, which String returns:
+//
+//	var LoginID = slogjournal.MustParseMessageID("8d45620c-1a43-48db-b174-10da57c60c66")
+//
+//	logger.Info("user logged in", LoginID.Attr())
+//
+// A MessageID is a [slog.LogValuer], so it can also be passed as the value
+// of any attribute.
+type MessageID [16]byte
+
+// NewMessageID returns a random MessageID, like systemd-id128 new. It is
+// meant for generating IDs once, which are then hard-coded in the program.
+func NewMessageID() (MessageID, error) {
+	var id MessageID
+	if _, err := rand.Read(id[:]); err != nil {
+		return MessageID{}, err
+	}
+	// Mark the ID as a random (version 4) UUID, as systemd does.
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return id, nil
+}
+
+// ParseMessageID parses s as 32 hexadecimal digits, or as a UUID with
+// dashes in the form 8-4-4-4-12 such as uuidgen prints. Upper and lower
+// case digits are accepted.
+func ParseMessageID(s string) (MessageID, error) {
+	var id MessageID
+	h := s
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return MessageID{}, fmt.Errorf("slogjournal: invalid message ID %q", s)
+		}
+		h = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(h) != 32 {
+		return MessageID{}, fmt.Errorf("slogjournal: invalid message ID %q: want 32 hexadecimal digits", s)
+	}
+	if _, err := hex.Decode(id[:], []byte(h)); err != nil {
+		return MessageID{}, fmt.Errorf("slogjournal: invalid message ID %q: %w", s, err)
+	}
+	return id, nil
+}
+
+// MustParseMessageID is like ParseMessageID but panics if s cannot be
+// parsed. It simplifies the initialization of global variables.
+func MustParseMessageID(s string) MessageID {
+	id, err := ParseMessageID(s)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// ValidMessageID reports whether s is a message ID in the form journald
+// expects: 32 lowercase hexadecimal digits.
+func ValidMessageID(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns id as 32 lowercase hexadecimal digits.
+func (id MessageID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// UUID returns id as a UUID with dashes, such as
+// 8d45620c-1a43-48db-b174-10da57c60c66.
+func (id MessageID) UUID() string {
+	s := id.String()
+	return strings.Join([]string{s[:8], s[8:12], s[12:16], s[16:20], s[20:]}, "-")
+}
+
+// IsZero reports whether id is the zero ID, which journald treats as
+// unset.
+func (id MessageID) IsZero() bool {
+	return id == MessageID{}
+}
+
+// Attr returns a MESSAGE_ID attribute holding id.
+func (id MessageID) Attr() slog.Attr {
+	return slog.String(MessageIDKey, id.String())
+}
+
+// LogValue implements [slog.LogValuer].
+func (id MessageID) LogValue() slog.Value {
+	return slog.StringValue(id.String())
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (id MessageID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler], accepting the forms
+// of ParseMessageID.
+func (id *MessageID) UnmarshalText(b []byte) error {
+	v, err := ParseMessageID(string(b))
+	if err != nil {
+		return err
+	}
+	*id = v
+	return nil
+}
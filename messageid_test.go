@@ -0,0 +1,73 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestParseMessageID(t *testing.T) {
+	const want = "8d45620c1a4348dbb17410da57c60c66"
+	for _, s := range []string{
+		want,
+		"8D45620C1A4348DBB17410DA57C60C66",
+		"8d45620c-1a43-48db-b174-10da57c60c66",
+	} {
+		id, err := ParseMessageID(s)
+		if err != nil {
+			t.Errorf("ParseMessageID(%q): %v", s, err)
+			continue
+		}
+		if id.String() != want {
+			t.Errorf("ParseMessageID(%q) = %s, want %s", s, id, want)
+		}
+		if id.UUID() != "8d45620c-1a43-48db-b174-10da57c60c66" {
+			t.Errorf("UUID() = %s", id.UUID())
+		}
+	}
+	for _, s := range []string{"", "8d45620c", "8d45620c1a4348dbb17410da57c60c6g", "8d45620c-1a43-48db-b174_10da57c60c66"} {
+		if _, err := ParseMessageID(s); err == nil {
+			t.Errorf("ParseMessageID(%q) succeeded", s)
+		}
+	}
+	if !ValidMessageID(want) || ValidMessageID("8D45620C1A4348DBB17410DA57C60C66") {
+		t.Error("ValidMessageID accepts only lowercase hex")
+	}
+}
+
+func TestNewMessageID(t *testing.T) {
+	a, err := NewMessageID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewMessageID()
+	if a == b || a.IsZero() {
+		t.Errorf("NewMessageID returned %s and %s", a, b)
+	}
+	if !ValidMessageID(a.String()) || a[6]>>4 != 4 {
+		t.Errorf("%s is not a version 4 ID", a)
+	}
+	var c MessageID
+	text, _ := a.MarshalText()
+	if err := c.UnmarshalText(text); err != nil || c != a {
+		t.Errorf("text round trip: %s, %v", c, err)
+	}
+}
+
+func TestMessageIDAttr(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	id := MustParseMessageID("8d45620c-1a43-48db-b174-10da57c60c66")
+	slog.New(h).Info("hello", id.Attr(), "OTHER_ID", id)
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["MESSAGE_ID"] != id.String() || kv["OTHER_ID"] != id.String() {
+		t.Errorf("MESSAGE_ID=%q OTHER_ID=%q, want %s", kv["MESSAGE_ID"], kv["OTHER_ID"], id)
+	}
+}
@@ -0,0 +1,78 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Middleware wraps a handler to enrich or filter its records. Its shape
+// matches the middleware of slog pipeline libraries such as slog-multi, so
+// the pieces of this package can be combined with others in front of a
+// terminal [Handler] returned by [NewHandler].
+type Middleware func(slog.Handler) slog.Handler
+
+// Chain returns h wrapped in every middleware in ms. The first middleware
+// sees records first.
+func Chain(h slog.Handler, ms ...Middleware) slog.Handler {
+	for i := len(ms) - 1; i >= 0; i-- {
+		h = ms[i](h)
+	}
+	return h
+}
+
+// NameMiddleware names the wrapped handler, see [NameKey]. A [Handler] gets
+// the name appended with [Handler.WithName]; other handlers get a [NameKey]
+// attribute.
+func NameMiddleware(name string) Middleware {
+	return func(h slog.Handler) slog.Handler {
+		if jh, ok := h.(*Handler); ok {
+			return jh.WithName(name)
+		}
+		return h.WithAttrs([]slog.Attr{slog.String(NameKey, name)})
+	}
+}
+
+// IdentifierMiddleware sets the SYSLOG_IDENTIFIER of the wrapped handler,
+// see [Handler.WithIdentifier]. Other handlers get a SYSLOG_IDENTIFIER
+// attribute.
+func IdentifierMiddleware(identifier string) Middleware {
+	return func(h slog.Handler) slog.Handler {
+		if jh, ok := h.(*Handler); ok {
+			return jh.WithIdentifier(identifier)
+		}
+		return h.WithAttrs([]slog.Attr{slog.String("SYSLOG_IDENTIFIER", identifier)})
+	}
+}
+
+// LevelMiddleware drops records below level before they reach the wrapped
+// handler.
+func LevelMiddleware(level slog.Leveler) Middleware {
+	return func(h slog.Handler) slog.Handler {
+		return &levelHandler{next: h, level: level}
+	}
+}
+
+// levelHandler is the handler returned by LevelMiddleware.
+type levelHandler struct {
+	next  slog.Handler
+	level slog.Leveler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= h.level.Level() && h.next.Enabled(ctx, l)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < h.level.Level() {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{next: h.next.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{next: h.next.WithGroup(name), level: h.level}
+}
@@ -0,0 +1,42 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{Level: slog.LevelDebug})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	logger := slog.New(Chain(h,
+		LevelMiddleware(slog.LevelInfo),
+		NameMiddleware("server"),
+		IdentifierMiddleware("myapp"),
+	))
+	logger.Debug("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.Bytes())
+	}
+	logger.Info("hello")
+	m, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m[NameKey] != "server" || m["SYSLOG_IDENTIFIER"] != "myapp" {
+		t.Errorf("unexpected fields %v", m)
+	}
+
+	// Other handlers get attributes.
+	var text strings.Builder
+	logger = slog.New(Chain(slog.NewTextHandler(&text, nil), NameMiddleware("server")))
+	logger.Info("hello")
+	if !strings.Contains(text.String(), "NAME=server") {
+		t.Errorf("unexpected output %q", text.String())
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !openbsd
+
+package slogjournal
+
+// monotonicUsec reports false, as CLOCK_MONOTONIC is not available.
+func monotonicUsec() (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,34 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"strconv"
+	"testing"
+)
+
+func TestMonotonicTimestamp(t *testing.T) {
+	before, ok := monotonicUsec()
+	if !ok {
+		t.Skip("CLOCK_MONOTONIC is not available")
+	}
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{MonotonicTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	slog.New(h).Info("hello")
+	after, _ := monotonicUsec()
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mono, err := strconv.ParseInt(kv["MONOTONIC_TIMESTAMP"], 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mono < before || mono > after {
+		t.Errorf("MONOTONIC_TIMESTAMP %d not in [%d, %d]", mono, before, after)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || dragonfly || freebsd || openbsd
+
+package slogjournal
+
+import "golang.org/x/sys/unix"
+
+// monotonicUsec returns the current CLOCK_MONOTONIC time in microseconds,
+// the clock journald's __MONOTONIC_TIMESTAMP is based on.
+func monotonicUsec() (int64, bool) {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return 0, false
+	}
+	return ts.Nano() / 1e3, true
+}
@@ -0,0 +1,68 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// NameKey is the key of the attribute that sets a logger's name. Passing a
+// string attribute with this key to [slog.Logger.With] (outside of any group)
+// names the logger: the name is sent as the NAME field, so the logger's
+// records can be selected with journalctl NAME=…, and it is used to look up
+// Options.LevelOverrides. A name like "server.http" is hierarchical: without
+// an override for "server.http", the one for "server" applies.
+const NameKey = "NAME"
+
+// WithName returns a logger whose name is l's name extended by name, see
+// [Handler.WithName]. If l does not use a [Handler], the [NameKey] attribute
+// is set to name instead.
+func WithName(l *slog.Logger, name string) *slog.Logger {
+	if h, ok := l.Handler().(*Handler); ok {
+		return slog.New(h.WithName(name))
+	}
+	return l.With(NameKey, name)
+}
+
+// WithName returns a new Handler whose logger name is the receiver's name
+// with name appended, separated by a dot. Unlike WithGroup, this does not
+// prefix any keys: the name is sent as a single NAME field, so operators can
+// query journalctl NAME=server.http. Level overrides for the new name are
+// resolved as described for [NameKey].
+func (h *Handler) WithName(name string) *Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	if h.name != "" {
+		name = h.name + "." + name
+	}
+	h2.setName(name)
+	return &h2
+}
+
+// setName sets h's logger name and the level override that applies to it.
+func (h *Handler) setName(name string) {
+	h.name = name
+	if l := h.lookupLevelOverride(name); l != nil {
+		h.level = l
+	}
+}
+
+// lookupLevelOverride returns the entry of Options.LevelOverrides for the
+// longest dotted prefix of name, or nil if there is none.
+func (h *Handler) lookupLevelOverride(name string) slog.Leveler {
+	if len(h.opts.LevelOverrides) == 0 {
+		return nil
+	}
+	for p := name; p != ""; {
+		if l, ok := h.opts.LevelOverrides[p]; ok {
+			return l
+		}
+		i := strings.LastIndexByte(p, '.')
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{
+		Level: slog.LevelDebug,
+		LevelOverrides: map[string]slog.Leveler{
+			"server": slog.LevelWarn,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	log := slog.New(h)
+
+	http := WithName(log, "server.http")
+	if http.Enabled(context.TODO(), slog.LevelInfo) {
+		t.Error("expected the server override to apply to server.http")
+	}
+	if !WithName(log, "client").Enabled(context.TODO(), slog.LevelDebug) {
+		t.Error("expected the default level for client")
+	}
+
+	http.Warn("hello", "KEY", "value")
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["NAME"] != "server.http" || kv["KEY"] != "value" {
+		t.Error("unexpected fields", kv)
+	}
+
+	// Within a group, NAME is an ordinary attribute.
+	log.WithGroup("G").With(NameKey, "x").Info("hello")
+	kv, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := kv["NAME"]; ok || kv["G_NAME"] != "x" {
+		t.Error("unexpected fields", kv)
+	}
+}
+
+func TestHandlerWithName(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{
+		Level: slog.LevelDebug,
+		LevelOverrides: map[string]slog.Leveler{
+			"server.http": slog.LevelError,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+
+	server := h.WithName("server")
+	http := server.WithName("http")
+	if !server.Enabled(context.TODO(), slog.LevelDebug) {
+		t.Error("expected server to log at debug")
+	}
+	if http.Enabled(context.TODO(), slog.LevelWarn) {
+		t.Error("expected server.http to log at error")
+	}
+
+	log := WithName(slog.New(http.WithGroup("REQ")), "conn")
+	log.Error("hello", "ID", "1")
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["NAME"] != "server.http.conn" || kv["REQ_ID"] != "1" {
+		t.Error("unexpected fields", kv)
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package slogjournal
+
+const raceEnabled = false
@@ -0,0 +1,45 @@
+package slogjournal
+
+import (
+	"net"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Notify sends state, such as "READY=1" or "STATUS=…", to the service
+// manager like sd_notify(3). Several assignments may be separated by
+// newlines. It does nothing if $NOTIFY_SOCKET is not set, that is if the
+// program was not started by systemd with notification access.
+func Notify(state string) error {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return nil
+	}
+	if strings.HasPrefix(addr, "\x00") {
+		addr = "@" + addr[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// maxStatusLength is the longest STATUS= text sent for Options.StatusLevel.
+const maxStatusLength = 256
+
+// notifyStatus sends msg as the service status text, on a single line and
+// truncated to maxStatusLength bytes.
+func notifyStatus(msg string) error {
+	msg = strings.ReplaceAll(msg, "\n", " ")
+	if len(msg) > maxStatusLength {
+		msg = msg[:maxStatusLength]
+		for !utf8.ValidString(msg) {
+			msg = msg[:len(msg)-1]
+		}
+	}
+	return Notify("STATUS=" + msg)
+}
@@ -0,0 +1,71 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listenNotify(t *testing.T) *net.UnixConn {
+	t.Helper()
+	addr := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", addr)
+	return conn
+}
+
+func readNotify(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := Notify("READY=1"); err != nil {
+		t.Errorf("expected no error without NOTIFY_SOCKET, got %v", err)
+	}
+
+	conn := listenNotify(t)
+	if err := Notify("READY=1"); err != nil {
+		t.Fatal(err)
+	}
+	if s := readNotify(t, conn); s != "READY=1" {
+		t.Errorf("unexpected state %q", s)
+	}
+}
+
+func TestStatusLevel(t *testing.T) {
+	conn := listenNotify(t)
+	h, err := NewHandler(&Options{StatusLevel: slog.LevelWarn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = new(bytes.Buffer)
+	logger := slog.New(h)
+
+	logger.Info("not significant")
+	logger.Warn("disk\nalmost full")
+	if s := readNotify(t, conn); s != "STATUS=disk almost full" {
+		t.Errorf("unexpected state %q", s)
+	}
+	logger.Error(strings.Repeat("x", 1000))
+	if s := readNotify(t, conn); s != "STATUS="+strings.Repeat("x", maxStatusLength) {
+		t.Errorf("unexpected state of length %d", len(s))
+	}
+}
@@ -0,0 +1,352 @@
+// Package otlpjournal forwards journal entries to an [OpenTelemetry] logs
+// endpoint using OTLP over HTTP with JSON encoding. An [Exporter] is meant
+// to be passed in Options.Mirrors, so a single handler writes to both the
+// journal and an OpenTelemetry collector:
+//
+//	exp := otlpjournal.NewExporter("http://localhost:4318/v1/logs", nil)
+//	defer exp.Shutdown(context.Background())
+//	h, err := slogjournal.NewHandler(&slogjournal.Options{
+//		Mirrors: []io.Writer{exp},
+//	})
+//
+// Journal fields are mapped to OpenTelemetry as follows: MESSAGE becomes the
+// body, PRIORITY the severity, SYSLOG_TIMESTAMP the timestamp, CODE_FILE,
+// CODE_FUNC and CODE_LINE the code.* attributes, SYSLOG_IDENTIFIER the
+// service.name resource attribute, and TRACE_ID and SPAN_ID the trace
+// context. All other fields become attributes with the field name as key.
+//
+// [OpenTelemetry]: https://opentelemetry.io/docs/specs/otlp/
+package otlpjournal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// Options configure an Exporter.
+type Options struct {
+	// Client sends the requests. The default is a client with a timeout
+	// of ten seconds.
+	Client *http.Client
+
+	// Header is added to every request, for example for authentication.
+	Header http.Header
+
+	// BatchSize is the largest number of entries sent in one request. The
+	// default is 512.
+	BatchSize int
+
+	// FlushInterval is the longest time an entry waits before it is sent.
+	// The default is one second.
+	FlushInterval time.Duration
+
+	// QueueSize is the number of entries waiting to be sent. Further
+	// entries are dropped. The default is 4096.
+	QueueSize int
+}
+
+// Exporter is an [io.Writer] that decodes journal entries and sends them to
+// an OTLP logs endpoint in batches from a background goroutine. Write never
+// blocks; entries arriving while the queue is full are dropped and counted.
+type Exporter struct {
+	url   string
+	opts  Options
+	queue chan []slogjournal.Field
+
+	ctx    context.Context    // context of the requests
+	cancel context.CancelFunc // aborts the requests when Shutdown gives up
+
+	mu      sync.RWMutex // guards closed and sending on queue
+	closed  bool
+	done    chan struct{}
+	dropped int64
+	err     error // last error, guarded by mu
+}
+
+// defaultTimeout limits the requests of the default client.
+const defaultTimeout = 10 * time.Second
+
+// NewExporter returns an Exporter posting to url, usually
+// http://host:4318/v1/logs. If opts is nil, the default options are used.
+func NewExporter(url string, opts *Options) *Exporter {
+	e := &Exporter{url: url, done: make(chan struct{})}
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	if opts != nil {
+		e.opts = *opts
+	}
+	if e.opts.Client == nil {
+		e.opts.Client = &http.Client{Timeout: defaultTimeout}
+	}
+	if e.opts.BatchSize <= 0 {
+		e.opts.BatchSize = 512
+	}
+	if e.opts.FlushInterval <= 0 {
+		e.opts.FlushInterval = time.Second
+	}
+	if e.opts.QueueSize <= 0 {
+		e.opts.QueueSize = 4096
+	}
+	e.queue = make(chan []slogjournal.Field, e.opts.QueueSize)
+	go e.run()
+	return e
+}
+
+// Write queues the journal entry p. It returns an error if p is not a
+// valid entry or the exporter was shut down.
+func (e *Exporter) Write(p []byte) (int, error) {
+	// The decoded values alias p, which must not be retained.
+	fs, err := slogjournal.Decode(bytes.Clone(p))
+	if err != nil {
+		return 0, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return 0, fmt.Errorf("otlpjournal: exporter is shut down")
+	}
+	select {
+	case e.queue <- fs:
+	default:
+		e.dropped++
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of entries dropped because the queue was full.
+func (e *Exporter) Dropped() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dropped
+}
+
+// Err returns the error of the last failed request, if any.
+func (e *Exporter) Err() error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.err
+}
+
+// Shutdown sends the queued entries and stops the exporter. If ctx is done
+// first, it aborts the request in flight, discards the remaining entries
+// and returns ctx's error.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.queue)
+	}
+	e.mu.Unlock()
+	defer e.cancel()
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	t := time.NewTicker(e.opts.FlushInterval)
+	defer t.Stop()
+	var batch [][]slogjournal.Field
+	for {
+		select {
+		case fs, ok := <-e.queue:
+			if !ok {
+				e.send(batch)
+				return
+			}
+			batch = append(batch, fs)
+			if len(batch) >= e.opts.BatchSize {
+				e.send(batch)
+				batch = nil
+			}
+		case <-t.C:
+			e.send(batch)
+			batch = nil
+		}
+	}
+}
+
+// send posts batch to the endpoint.
+func (e *Exporter) send(batch [][]slogjournal.Field) {
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(encode(batch))
+	if err == nil {
+		err = e.post(body)
+	}
+	e.mu.Lock()
+	e.err = err
+	e.mu.Unlock()
+}
+
+func (e *Exporter) post(body []byte) error {
+	req, err := http.NewRequestWithContext(e.ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, vs := range e.opts.Header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlpjournal: %s: %s", e.url, resp.Status)
+	}
+	return nil
+}
+
+// The types below are the subset of the OTLP JSON encoding used by the
+// exporter.
+
+type logsData struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes,omitempty"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano,omitempty"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber,omitempty"`
+	SeverityText         string     `json:"severityText,omitempty"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes,omitempty"`
+	TraceID              string     `json:"traceId,omitempty"`
+	SpanID               string     `json:"spanId,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+	IntValue    string `json:"intValue,omitempty"` // an int64 in decimal
+}
+
+// MarshalJSON encodes v as an intValue if it holds one, and as a
+// stringValue otherwise.
+func (v anyValue) MarshalJSON() ([]byte, error) {
+	if v.IntValue != "" {
+		return json.Marshal(struct {
+			IntValue string `json:"intValue"`
+		}{v.IntValue})
+	}
+	return json.Marshal(struct {
+		StringValue string `json:"stringValue"`
+	}{v.StringValue})
+}
+
+// scopeName is the instrumentation scope of the exported records.
+const scopeName = "github.com/systemd/slog-journal/otlpjournal"
+
+// severities maps journal priorities to OpenTelemetry severity numbers and
+// texts.
+var severities = [...]struct {
+	number int
+	text   string
+}{
+	0: {21, "FATAL"},
+	1: {19, "ERROR3"},
+	2: {18, "ERROR2"},
+	3: {17, "ERROR"},
+	4: {13, "WARN"},
+	5: {10, "INFO2"},
+	6: {9, "INFO"},
+	7: {5, "DEBUG"},
+}
+
+// encode converts journal entries into an OTLP logs export request,
+// grouping them by SYSLOG_IDENTIFIER, which becomes the service.name
+// resource attribute.
+func encode(entries [][]slogjournal.Field) logsData {
+	var data logsData
+	index := make(map[string]int)
+	observed := strconv.FormatInt(time.Now().UnixNano(), 10)
+	for _, fs := range entries {
+		var service string
+		rec := logRecord{ObservedTimeUnixNano: observed}
+		for _, f := range fs {
+			v := string(f.Value)
+			switch f.Key {
+			case "MESSAGE":
+				rec.Body.StringValue = v
+			case "PRIORITY":
+				if p, err := strconv.Atoi(v); err == nil && p >= 0 && p < len(severities) {
+					rec.SeverityNumber = severities[p].number
+					rec.SeverityText = severities[p].text
+				}
+			case "SYSLOG_TIMESTAMP":
+				if t, ok := slogjournal.ParseSyslogTimestamp(v); ok {
+					rec.TimeUnixNano = strconv.FormatInt(t.UnixNano(), 10)
+				}
+			case "SYSLOG_IDENTIFIER":
+				service = v
+			case "TRACE_ID":
+				rec.TraceID = v
+			case "SPAN_ID":
+				rec.SpanID = v
+			case "CODE_FILE":
+				rec.Attributes = append(rec.Attributes, keyValue{"code.filepath", anyValue{StringValue: v}})
+			case "CODE_FUNC":
+				rec.Attributes = append(rec.Attributes, keyValue{"code.function", anyValue{StringValue: v}})
+			case "CODE_LINE":
+				line := anyValue{StringValue: v}
+				if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+					line = anyValue{IntValue: v}
+				}
+				rec.Attributes = append(rec.Attributes, keyValue{"code.lineno", line})
+			default:
+				rec.Attributes = append(rec.Attributes, keyValue{f.Key, anyValue{StringValue: v}})
+			}
+		}
+		i, ok := index[service]
+		if !ok {
+			i = len(data.ResourceLogs)
+			index[service] = i
+			rl := resourceLogs{ScopeLogs: []scopeLogs{{Scope: scope{Name: scopeName}}}}
+			if service != "" {
+				rl.Resource.Attributes = []keyValue{{"service.name", anyValue{StringValue: service}}}
+			}
+			data.ResourceLogs = append(data.ResourceLogs, rl)
+		}
+		sl := &data.ResourceLogs[i].ScopeLogs[0]
+		sl.LogRecords = append(sl.LogRecords, rec)
+	}
+	return data
+}
@@ -0,0 +1,124 @@
+package otlpjournal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+	"github.com/systemd/slog-journal/journaltest"
+)
+
+func TestExporter(t *testing.T) {
+	var mu sync.Mutex
+	var requests []logsData
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "secret" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		var data logsData
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		requests = append(requests, data)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	exp := NewExporter(collector.URL+"/v1/logs", &Options{
+		Header:        http.Header{"Authorization": {"secret"}},
+		FlushInterval: time.Hour,
+	})
+	srv := journaltest.NewServer(t)
+	h, err := slogjournal.NewHandler(&slogjournal.Options{
+		Addr:       srv.Addr,
+		Identifier: "myapp",
+		Mirrors:    []io.Writer{exp},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(h)
+	logger.Warn("hello", "USER", "alice")
+	logger.Info("world")
+	if err := exp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	srv.WaitEntries(t, 2)
+	if err := exp.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 1 {
+		t.Fatalf("expected a single request, got %d", len(requests))
+	}
+	rls := requests[0].ResourceLogs
+	if len(rls) != 1 || len(rls[0].Resource.Attributes) != 1 || rls[0].Resource.Attributes[0].Value.StringValue != "myapp" {
+		t.Fatalf("unexpected resource logs %+v", rls)
+	}
+	recs := rls[0].ScopeLogs[0].LogRecords
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(recs))
+	}
+	rec := recs[0]
+	if rec.Body.StringValue != "hello" || rec.SeverityText != "WARN" || rec.SeverityNumber != 13 || rec.TimeUnixNano == "" {
+		t.Errorf("unexpected record %+v", rec)
+	}
+	var user, line bool
+	for _, kv := range rec.Attributes {
+		switch {
+		case kv.Key == "USER" && kv.Value.StringValue == "alice":
+			user = true
+		case kv.Key == "code.lineno" && kv.Value.IntValue != "" && kv.Value.StringValue == "":
+			line = true
+		}
+	}
+	if !user {
+		t.Errorf("missing USER attribute in %+v", rec.Attributes)
+	}
+	if !line {
+		t.Errorf("missing integer code.lineno attribute in %+v", rec.Attributes)
+	}
+
+	if _, err := exp.Write([]byte("MESSAGE=late\n")); err == nil {
+		t.Error("expected an error after Shutdown")
+	}
+}
+
+func TestExporterShutdownAborts(t *testing.T) {
+	stuck := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		close(stuck)
+		<-r.Context().Done()
+	}))
+	defer collector.Close()
+
+	exp := NewExporter(collector.URL+"/v1/logs", &Options{FlushInterval: time.Millisecond})
+	if _, err := exp.Write([]byte("MESSAGE=hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	<-stuck
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := exp.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown returned %v", err)
+	}
+	select {
+	case <-exp.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request was not aborted")
+	}
+	if exp.Err() == nil {
+		t.Error("expected the aborted request to fail")
+	}
+}
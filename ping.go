@@ -0,0 +1,26 @@
+package slogjournal
+
+// Ping checks that the journal socket at addr exists and accepts datagrams
+// by sending it an empty one, which journald ignores. If addr is empty, the
+// socket of the default journal namespace is used. Unlike a [Handler], which
+// silently drops entries while the journal is missing, Ping reports the
+// error, so programs can decide at startup whether to log to the journal or
+// to a fallback.
+func Ping(addr string) error {
+	if addr == "" {
+		addr = journalSocket("")
+	}
+	w, err := newJournalWriter(addr)
+	if err != nil {
+		return err
+	}
+	defer w.conn.Close()
+	_, err = w.conn.WriteToUnix(nil, w.addr)
+	return err
+}
+
+// IsJournalAvailable reports whether the journal of the default namespace
+// accepts entries. See [Ping].
+func IsJournalAvailable() bool {
+	return Ping("") == nil
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "socket")
+	if err := Ping(addr); err == nil {
+		t.Error("expected an error for a missing socket")
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := Ping(addr); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	n, err := conn.Read(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("expected an empty datagram, got %d bytes", n)
+	}
+}
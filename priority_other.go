@@ -0,0 +1,19 @@
+//go:build windows || plan9
+
+package slogjournal
+
+// Priority is a syslog priority, as sent in the PRIORITY field. On
+// platforms with the log/syslog package it is the same type as
+// syslog.Priority.
+type Priority int
+
+const (
+	logEmerg Priority = iota
+	logAlert
+	logCrit
+	logErr
+	logWarning
+	logNotice
+	logInfo
+	logDebug
+)
@@ -0,0 +1,20 @@
+//go:build !windows && !plan9
+
+package slogjournal
+
+import "log/syslog"
+
+// Priority is a syslog priority, as sent in the PRIORITY field. It is the
+// same type as [syslog.Priority], which is not available on every platform.
+type Priority = syslog.Priority
+
+const (
+	logEmerg   = syslog.LOG_EMERG
+	logAlert   = syslog.LOG_ALERT
+	logCrit    = syslog.LOG_CRIT
+	logErr     = syslog.LOG_ERR
+	logWarning = syslog.LOG_WARNING
+	logNotice  = syslog.LOG_NOTICE
+	logInfo    = syslog.LOG_INFO
+	logDebug   = syslog.LOG_DEBUG
+)
@@ -0,0 +1,149 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SuppressedKey is the field holding the number of records that were
+// suppressed by rate limiting, see Options.RateLimitInterval.
+const SuppressedKey = "SUPPRESSED"
+
+// rateLimiter throttles records per MESSAGE_ID or message. It is shared by
+// a handler and all handlers derived from it.
+type rateLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+	swept   time.Time // when expired buckets were last removed
+}
+
+// maxRateBuckets bounds the number of keys a rateLimiter tracks, so that
+// programs logging unbounded sets of messages do not leak memory. Records
+// with new keys are not rate limited while the limit is reached.
+const maxRateBuckets = 10000
+
+// rateBucket counts the records of one key in the current window.
+type rateBucket struct {
+	start      time.Time
+	count      int
+	suppressed int
+	// report is the handler and the MESSAGE_ID used to report suppressed
+	// records when the window closes.
+	report *Handler
+	id     string
+}
+
+func newRateLimiter(interval time.Duration, burst int) *rateLimiter {
+	return &rateLimiter{
+		interval: interval,
+		burst:    burst,
+		buckets:  make(map[string]*rateBucket),
+	}
+}
+
+// allow reports whether a record with the given key may be logged by h at
+// time now. The first suppressed record of a window schedules a report of
+// how many records were suppressed when the window closes.
+func (l *rateLimiter) allow(h *Handler, key, id string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.swept) >= l.interval {
+		l.sweep(now)
+	}
+	b := l.buckets[key]
+	if b == nil && len(l.buckets) >= maxRateBuckets {
+		return true
+	}
+	if b == nil || now.Sub(b.start) >= l.interval {
+		if b != nil && b.suppressed > 0 {
+			// The report of the previous window is about to run; keep
+			// the bucket so it finds the count.
+			b.start, b.count = now, 1
+			return true
+		}
+		l.buckets[key] = &rateBucket{start: now, count: 1}
+		return true
+	}
+	if b.count < l.burst {
+		b.count++
+		return true
+	}
+	if b.suppressed == 0 {
+		b.report, b.id = h, id
+		time.AfterFunc(b.start.Add(l.interval).Sub(now), func() { l.flush(key) })
+	}
+	b.suppressed++
+	return false
+}
+
+// sweep removes the buckets whose window closed before now and that have
+// no report pending.
+func (l *rateLimiter) sweep(now time.Time) {
+	l.swept = now
+	for key, b := range l.buckets {
+		if b.suppressed == 0 && now.Sub(b.start) >= l.interval {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// flush reports the records suppressed for key.
+func (l *rateLimiter) flush(key string) {
+	l.mu.Lock()
+	b := l.buckets[key]
+	if b == nil || b.suppressed == 0 {
+		l.mu.Unlock()
+		return
+	}
+	n, h, id := b.suppressed, b.report, b.id
+	b.suppressed, b.report = 0, nil
+	l.mu.Unlock()
+
+	msg := fmt.Sprintf("Suppressed %d messages", n)
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, msg, 0)
+	r.AddAttrs(slog.Int(SuppressedKey, n))
+	if id != "" {
+		r.AddAttrs(slog.String("SUPPRESSED_"+MessageIDKey, id))
+	}
+	h2 := *h
+	h2.limiter = nil
+	h2.prefix = ""
+	h2.groups = nil
+	_ = h2.Handle(context.Background(), r)
+}
+
+// rateLimitKey returns the key the rate limit of r is tracked by: its
+// MESSAGE_ID if it has one, and a hash of its message otherwise. It also
+// returns the MESSAGE_ID.
+func (h *Handler) rateLimitKey(r slog.Record) (key, id string) {
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == MessageIDKey {
+			id = a.Value.Resolve().String()
+			return false
+		}
+		return true
+	})
+	if id == "" && bytes.Contains(h.preformatted, []byte(MessageIDKey)) {
+		for _, f := range parseFields(h.preformatted) {
+			if f.Key == MessageIDKey {
+				id = string(f.Value)
+				break
+			}
+		}
+	}
+	if id != "" {
+		return "id:" + id, id
+	}
+	sum := fnv.New64a()
+	sum.Write([]byte(r.Message))
+	return "msg:" + strconv.FormatUint(sum.Sum64(), 16), ""
+}
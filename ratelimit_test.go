@@ -0,0 +1,87 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	w := &entryWriter{}
+	h, err := NewHandler(&Options{RateLimitInterval: 100 * time.Millisecond, RateLimitBurst: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = w
+	logger := slog.New(h.WithGroup("G"))
+
+	for i := 0; i < 5; i++ {
+		_ = h.LogEvent(context.TODO(), slog.LevelError, diskFull{})
+		logger.Info("noisy")
+	}
+	logger.Info("quiet")
+	want := []string{"disk full", "noisy", "disk full", "noisy", "quiet"}
+	if msgs := w.messages(); !slices.Equal(msgs, want) {
+		t.Fatalf("expected %q, got %q", want, msgs)
+	}
+	if s := h.Stats(); s.Dropped != 6 {
+		t.Errorf("expected 6 suppressed records to be dropped, stats %+v", s)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(w.messages()) < 7 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	w.mu.Lock()
+	reports := w.entries[5:]
+	w.mu.Unlock()
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+	ids := 0
+	for _, e := range reports {
+		m, err := deserializeKeyValue(bytes.NewReader(e))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m["MESSAGE"] != "Suppressed 3 messages" || m[SuppressedKey] != "3" {
+			t.Errorf("unexpected report %v", m)
+		}
+		if m["SUPPRESSED_MESSAGE_ID"] == (diskFull{}).MessageID() {
+			ids++
+		}
+	}
+	if ids != 1 {
+		t.Errorf("expected one report for the event")
+	}
+
+	// A new window starts afterwards.
+	logger.Info("noisy")
+	if msgs := w.messages(); msgs[len(msgs)-1] != "noisy" {
+		t.Errorf("expected the record to be logged, got %q", msgs)
+	}
+}
+
+func TestRateLimitBuckets(t *testing.T) {
+	l := newRateLimiter(time.Second, 1)
+	now := time.Now()
+	for i := 0; i < maxRateBuckets+10; i++ {
+		if !l.allow(nil, strconv.Itoa(i), "", now) {
+			t.Fatalf("record %d was suppressed", i)
+		}
+	}
+	if n := len(l.buckets); n != maxRateBuckets {
+		t.Errorf("expected %d buckets, got %d", maxRateBuckets, n)
+	}
+
+	// Expired buckets are removed once the window has passed.
+	now = now.Add(time.Second)
+	l.allow(nil, "new", "", now)
+	if n := len(l.buckets); n != 1 {
+		t.Errorf("expected expired buckets to be removed, got %d", n)
+	}
+}
@@ -0,0 +1,188 @@
+// Package reader inspects the journal files on disk, giving Go programs the
+// information journalctl --disk-usage and journalctl --header show: how
+// much space the journal takes up, and which time range every file covers.
+//
+//	usage, err := reader.Usage()
+//	for dir, n := range usage {
+//		fmt.Printf("%s: %d bytes\n", dir, n)
+//	}
+//
+// Only the file headers are read; the package does not decode entries.
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultDirs are the directories journald stores journal files in: the
+// persistent and the volatile journal.
+var DefaultDirs = []string{"/var/log/journal", "/run/log/journal"}
+
+// FileState is the state recorded in the header of a journal file.
+type FileState uint8
+
+const (
+	// StateOffline means the file is closed cleanly.
+	StateOffline FileState = iota
+	// StateOnline means the file is being written to, or journald did
+	// not close it cleanly.
+	StateOnline
+	// StateArchived means the file was rotated and is no longer written to.
+	StateArchived
+)
+
+func (s FileState) String() string {
+	switch s {
+	case StateOffline:
+		return "offline"
+	case StateOnline:
+		return "online"
+	case StateArchived:
+		return "archived"
+	}
+	return fmt.Sprintf("FileState(%d)", uint8(s))
+}
+
+// File describes a journal file.
+type File struct {
+	// Path is the path of the file.
+	Path string
+	// Size is the disk space the file takes up in bytes, which, as the
+	// files are allocated in advance, is usually more than their contents.
+	Size int64
+	// MachineID is the ID of the machine that wrote the file, as 32
+	// hexadecimal digits.
+	MachineID string
+	// State is the state of the file.
+	State FileState
+	// Entries is the number of entries in the file.
+	Entries uint64
+	// Head and Tail are the times of the first and last entry in the file.
+	// They are zero if the file has no entries.
+	Head, Tail time.Time
+}
+
+// Journal files start with this signature.
+var signature = []byte("LPKSHHRH")
+
+// Offsets of the header fields read, see
+// https://systemd.io/JOURNAL_FILE_FORMAT/.
+const (
+	offState        = 16
+	offMachineID    = 40
+	offEntries      = 152
+	offHeadRealtime = 184
+	offTailRealtime = 192
+	headerSize      = 200
+)
+
+// ErrNotJournal is returned by ReadFile for files that are not journal
+// files.
+var ErrNotJournal = errors.New("reader: not a journal file")
+
+// ReadFile reads the header of the journal file at path.
+func ReadFile(path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return File{}, err
+	}
+	var h [headerSize]byte
+	if _, err := io.ReadFull(f, h[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return File{}, fmt.Errorf("%s: %w", path, ErrNotJournal)
+		}
+		return File{}, err
+	}
+	if !bytes.Equal(h[:len(signature)], signature) {
+		return File{}, fmt.Errorf("%s: %w", path, ErrNotJournal)
+	}
+	le := binary.LittleEndian
+	file := File{
+		Path:      path,
+		Size:      diskSize(fi),
+		MachineID: hex.EncodeToString(h[offMachineID : offMachineID+16]),
+		State:     FileState(h[offState]),
+		Entries:   le.Uint64(h[offEntries:]),
+	}
+	if file.Entries > 0 {
+		file.Head = time.UnixMicro(int64(le.Uint64(h[offHeadRealtime:])))
+		file.Tail = time.UnixMicro(int64(le.Uint64(h[offTailRealtime:])))
+	}
+	return file, nil
+}
+
+// Files returns the journal files in dirs and their subdirectories, which
+// hold the files of a machine ID or journal namespace, sorted by the time
+// of their first entry. If no directories are given, DefaultDirs are used.
+// Directories that do not exist are skipped.
+func Files(dirs ...string) ([]File, error) {
+	if len(dirs) == 0 {
+		dirs = DefaultDirs
+	}
+	var files []File
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == dir && errors.Is(err, fs.ErrNotExist) {
+					return fs.SkipDir
+				}
+				return err
+			}
+			if d.IsDir() || !isJournalFile(d.Name()) {
+				return nil
+			}
+			f, err := ReadFile(path)
+			if errors.Is(err, ErrNotJournal) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			files = append(files, f)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Head.Before(files[j].Head) })
+	return files, nil
+}
+
+// isJournalFile reports whether name is the name of an active or archived
+// journal file. Files journald renamed after finding them corrupted end in
+// a tilde.
+func isJournalFile(name string) bool {
+	return strings.HasSuffix(name, ".journal") || strings.HasSuffix(name, ".journal~")
+}
+
+// Usage returns the disk space taken up by the journal files in dirs, or
+// DefaultDirs if none are given, in bytes per directory holding files,
+// like journalctl --disk-usage reports it in total.
+func Usage(dirs ...string) (map[string]int64, error) {
+	files, err := Files(dirs...)
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[string]int64)
+	for _, f := range files {
+		usage[filepath.Dir(f.Path)] += f.Size
+	}
+	return usage, nil
+}
@@ -0,0 +1,83 @@
+package reader_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/systemd/slog-journal/reader"
+)
+
+// writeJournal writes a journal file header with the given entries and
+// times to path.
+func writeJournal(t *testing.T, path string, state byte, entries uint64, head, tail time.Time) {
+	t.Helper()
+	h := make([]byte, 4096)
+	copy(h, "LPKSHHRH")
+	h[16] = state
+	for i := range 16 {
+		h[40+i] = byte(i)
+	}
+	binary.LittleEndian.PutUint64(h[152:], entries)
+	binary.LittleEndian.PutUint64(h[184:], uint64(head.UnixMicro()))
+	binary.LittleEndian.PutUint64(h[192:], uint64(tail.UnixMicro()))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, h, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	machine := filepath.Join(dir, "persistent", "000102030405060708090a0b0c0d0e0f")
+	t0 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	writeJournal(t, filepath.Join(machine, "system.journal"), 1, 10, t0.Add(time.Hour), t0.Add(2*time.Hour))
+	writeJournal(t, filepath.Join(machine, "system@0001-0002.journal"), 2, 5, t0, t0.Add(time.Minute))
+	writeJournal(t, filepath.Join(dir, "volatile", "user-1000.journal"), 0, 0, time.Time{}, time.Time{})
+	if err := os.WriteFile(filepath.Join(machine, "notes.journal"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(machine, "README"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := reader.Files(filepath.Join(dir, "persistent"), filepath.Join(dir, "volatile"), filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("got %d files, want 3: %+v", len(files), files)
+	}
+	empty, archived, online := files[0], files[1], files[2]
+	if !empty.Head.IsZero() || empty.Entries != 0 || empty.State != reader.StateOffline {
+		t.Errorf("empty file: %+v", empty)
+	}
+	if !archived.Head.Equal(t0) || !archived.Tail.Equal(t0.Add(time.Minute)) || archived.State != reader.StateArchived {
+		t.Errorf("archived file: %+v", archived)
+	}
+	if online.Entries != 10 || online.State != reader.StateOnline || online.MachineID != "000102030405060708090a0b0c0d0e0f" {
+		t.Errorf("online file: %+v", online)
+	}
+	for _, f := range files {
+		if f.Size <= 0 {
+			t.Errorf("%s: size %d", f.Path, f.Size)
+		}
+	}
+
+	usage, err := reader.Usage(filepath.Join(dir, "persistent"), filepath.Join(dir, "volatile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 2 || usage[machine] != archived.Size+online.Size {
+		t.Errorf("usage %v", usage)
+	}
+
+	if _, err := reader.ReadFile(filepath.Join(machine, "notes.journal")); !errors.Is(err, reader.ErrNotJournal) {
+		t.Errorf("ReadFile of text file: %v", err)
+	}
+}
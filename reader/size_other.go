@@ -0,0 +1,11 @@
+//go:build !unix
+
+package reader
+
+import "io/fs"
+
+// diskSize returns the size of fi, as the space it takes up on disk is not
+// known.
+func diskSize(fi fs.FileInfo) int64 {
+	return fi.Size()
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package reader
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// diskSize returns the space fi takes up on disk, like du does.
+func diskSize(fi fs.FileInfo) int64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return int64(st.Blocks) * 512
+	}
+	return fi.Size()
+}
@@ -0,0 +1,169 @@
+package slogjournal
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RecordJSONKey is the field holding the whole record as JSON if
+// Options.RecordJSON is set.
+const RecordJSONKey = "RECORD_JSON"
+
+// groupOrAttrs is a group or attributes added to a handler, kept in order
+// for Options.RecordJSON since the journal fields flatten them.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// withGroupOrAttrs returns goas with g appended without modifying goas.
+func withGroupOrAttrs(goas []groupOrAttrs, g groupOrAttrs) []groupOrAttrs {
+	return append(slices.Clip(goas), g)
+}
+
+// appendRecordJSON appends r, including the attributes and groups added to
+// h, as a JSON object in the layout of [slog.JSONHandler]: time, level and
+// msg followed by the attributes, with groups as nested objects.
+// Options.ReplaceAttr is applied to the attributes.
+func (h *Handler) appendRecordJSON(b []byte, r slog.Record, msg string) []byte {
+	b = append(b, '{')
+	if !r.Time.IsZero() {
+		b = append(b, `"time":`...)
+		b = appendJSONString(b, r.Time.Format(time.RFC3339Nano))
+		b = append(b, ',')
+	}
+	b = append(b, `"level":`...)
+	b = appendJSONString(b, r.Level.String())
+	b = append(b, `,"msg":`...)
+	b = appendJSONString(b, msg)
+
+	// sep is the separator before the next member of the innermost
+	// object; it is empty right after an opening brace.
+	sep := ","
+	var groups []string
+	open := 0
+	emit := func(a slog.Attr) {
+		var ok bool
+		if b, ok = h.appendJSONAttr(b, sep, groups, a); ok {
+			sep = ","
+		}
+	}
+	// Like slog.JSONHandler, leave out trailing groups that would be empty.
+	goas := h.goas
+	if r.NumAttrs() == 0 {
+		for len(goas) > 0 && goas[len(goas)-1].group != "" {
+			goas = goas[:len(goas)-1]
+		}
+	}
+	for _, g := range goas {
+		if g.group != "" {
+			b = append(b, sep...)
+			b = appendJSONString(b, g.group)
+			b = append(b, ":{"...)
+			sep = ""
+			groups = append(groups, g.group)
+			open++
+			continue
+		}
+		for _, a := range g.attrs {
+			emit(a)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		emit(a)
+		return true
+	})
+	for range open {
+		b = append(b, '}')
+	}
+	return append(b, '}')
+}
+
+// appendJSONAttr appends a as a member of a JSON object, preceded by sep.
+// It reports false if a was left out.
+func (h *Handler) appendJSONAttr(b []byte, sep string, groups []string, a slog.Attr) ([]byte, bool) {
+	a.Value = a.Value.Resolve()
+	if rep := h.opts.ReplaceAttr; rep != nil && a.Value.Kind() != slog.KindGroup {
+		a = rep(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return b, false
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return b, false
+		}
+		if a.Key == "" {
+			var any bool
+			for _, ga := range attrs {
+				var ok bool
+				if b, ok = h.appendJSONAttr(b, sep, groups, ga); ok {
+					sep, any = ",", true
+				}
+			}
+			return b, any
+		}
+		b = append(b, sep...)
+		b = appendJSONString(b, a.Key)
+		b = append(b, ":{"...)
+		inner := ""
+		for _, ga := range attrs {
+			var ok bool
+			if b, ok = h.appendJSONAttr(b, inner, append(slices.Clip(groups), a.Key), ga); ok {
+				inner = ","
+			}
+		}
+		return append(b, '}'), true
+	}
+	b = append(b, sep...)
+	b = appendJSONString(b, a.Key)
+	b = append(b, ':')
+	return appendJSONValue(b, a.Value), true
+}
+
+// appendJSONValue appends v as JSON, encoding it like slog.JSONHandler.
+func appendJSONValue(b []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendJSONString(b, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(b, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(b, v.Uint64(), 10)
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return appendJSONString(b, strconv.FormatFloat(f, 'g', -1, 64))
+		}
+		return strconv.AppendFloat(b, f, 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(b, v.Bool())
+	case slog.KindDuration:
+		return strconv.AppendInt(b, int64(v.Duration()), 10)
+	case slog.KindTime:
+		return appendJSONString(b, v.Time().Format(time.RFC3339Nano))
+	}
+	x := v.Any()
+	if err, ok := x.(error); ok {
+		if _, ok := x.(json.Marshaler); !ok {
+			return appendJSONString(b, err.Error())
+		}
+	}
+	js, err := json.Marshal(x)
+	if err != nil {
+		return appendJSONString(b, "!ERROR:"+err.Error())
+	}
+	return append(b, js...)
+}
+
+// appendJSONString appends s as a JSON string.
+func appendJSONString(b []byte, s string) []byte {
+	js, _ := json.Marshal(s)
+	return append(b, js...)
+}
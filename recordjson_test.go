@@ -0,0 +1,52 @@
+package slogjournal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordJSON(t *testing.T) {
+	buf := new(entryWriter)
+	h, err := NewHandler(&Options{RecordJSON: true, Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	want := new(bytes.Buffer)
+	jh := slog.NewJSONHandler(want, nil)
+
+	log := func(h slog.Handler) {
+		slog.New(h).With("A", 1).WithGroup("G").With("B", "two").WithGroup("EMPTY").Info("hello",
+			"C", 1.5, "D", time.Second, slog.Group("H", "E", true), "ERR", errors.New("boom"))
+	}
+	log(h)
+	log(jh)
+
+	var got, exp map[string]any
+	for _, f := range parseFields(buf.entries[0]) {
+		if f.Key == RecordJSONKey {
+			if err := json.Unmarshal(f.Value, &got); err != nil {
+				t.Fatalf("%v: %s", err, f.Value)
+			}
+		}
+	}
+	if err := json.Unmarshal(want.Bytes(), &exp); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil {
+		t.Fatal("no RECORD_JSON field")
+	}
+	if _, ok := got["time"]; !ok {
+		t.Error("missing time")
+	}
+	delete(got, "time")
+	delete(exp, "time")
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %v, want %v", got, exp)
+	}
+}
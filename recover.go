@@ -0,0 +1,68 @@
+package slogjournal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// RecoverAndLog logs a panic of the calling goroutine at LevelCritical, with
+// the panic value as PANIC_VALUE field and the stack trace as PANIC_STACK,
+// and then panics again with the same value. It must be deferred directly:
+//
+//	defer slogjournal.RecoverAndLog(logger)
+//
+// That way the reason a service crashed is in the journal as a structured
+// entry, not only as unstructured text on standard error.
+func RecoverAndLog(logger *slog.Logger) {
+	if v := recover(); v != nil {
+		logPanic(logger, v)
+		panic(v)
+	}
+}
+
+// RecoverHandler returns an [http.Handler] that serves requests with next
+// and logs panics in it like [RecoverAndLog], but responds with status 500
+// instead of crashing. Panics with [http.ErrAbortHandler] are passed on
+// unlogged, as the http package expects.
+func RecoverHandler(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+			logPanic(logger, v, slog.String("REQUEST_METHOD", r.Method), slog.String("REQUEST_PATH", r.URL.Path))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logPanic logs the recovered panic value v. Its CODE_* fields point at
+// the function that panicked.
+func logPanic(logger *slog.Logger, v any, attrs ...slog.Attr) {
+	ctx := context.Background()
+	if !logger.Enabled(ctx, LevelCritical) {
+		return
+	}
+	var pcs [1]uintptr
+	// Skip runtime.Callers, logPanic, the deferred function and
+	// runtime.gopanic.
+	runtime.Callers(4, pcs[:])
+	r := slog.NewRecord(time.Now(), LevelCritical, fmt.Sprintf("panic: %v", v), pcs[0])
+	r.AddAttrs(
+		slog.String("PANIC_VALUE", fmt.Sprint(v)),
+		slog.String("PANIC_STACK", string(debug.Stack())),
+	)
+	r.AddAttrs(attrs...)
+	_ = logger.Handler().Handle(ctx, r)
+}
@@ -0,0 +1,88 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func panicky() {
+	panic("boom")
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("expected the panic to continue, got %v", v)
+			}
+		}()
+		defer RecoverAndLog(slog.New(h))
+		panicky()
+	}()
+
+	m, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["PRIORITY"] != "2" || m["PANIC_VALUE"] != "boom" || m["MESSAGE"] != "panic: boom" {
+		t.Errorf("unexpected entry %v", m)
+	}
+	if !strings.Contains(m["PANIC_STACK"], "panicky") {
+		t.Errorf("unexpected stack %q", m["PANIC_STACK"])
+	}
+	if !strings.HasSuffix(m["CODE_FUNC"], ".panicky") {
+		t.Errorf("unexpected CODE_FUNC %q", m["CODE_FUNC"])
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+
+	srv := RecoverHandler(slog.New(h), http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panicky()
+	}))
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/crash", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", rec.Code)
+	}
+	m, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["PANIC_VALUE"] != "boom" || m["REQUEST_PATH"] != "/crash" {
+		t.Errorf("unexpected entry %v", m)
+	}
+
+	buf.Reset()
+	abort := RecoverHandler(slog.New(h), http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	func() {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Errorf("expected ErrAbortHandler, got %v", v)
+			}
+		}()
+		abort.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	if buf.Len() != 0 {
+		t.Errorf("unexpected entry %q", buf.Bytes())
+	}
+}
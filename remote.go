@@ -0,0 +1,88 @@
+package slogjournal
+
+import (
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+)
+
+// remoteConn is a connection to a log server that is redialed when it
+// breaks. It is safe for concurrent use.
+type remoteConn struct {
+	network, addr string
+	tlsConfig     *tls.Config
+	timeout       time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// dial connects to the server. TLS is only used over stream connections.
+// The caller must hold r.mu or have exclusive access to r.
+func (r *remoteConn) dial() error {
+	d := &net.Dialer{Timeout: r.timeout}
+	var conn net.Conn
+	var err error
+	if r.tlsConfig != nil && r.stream() {
+		conn, err = tls.DialWithDialer(d, r.network, r.addr, r.tlsConfig)
+	} else {
+		conn, err = d.Dial(r.network, r.addr)
+	}
+	if err != nil {
+		return err
+	}
+	r.conn = conn
+	return nil
+}
+
+// stream reports whether messages are sent over a stream connection and
+// need framing.
+func (r *remoteConn) stream() bool {
+	switch r.network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return false
+	}
+	return true
+}
+
+// write sends each of msgs with a separate Write call. If a stream
+// connection was closed by the server, it is redialed once.
+func (r *remoteConn) write(msgs ...[]byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		if r.conn == nil {
+			if err := r.dial(); err != nil {
+				return err
+			}
+		}
+		r.conn.SetWriteDeadline(time.Now().Add(r.timeout))
+		var err error
+		for _, msg := range msgs {
+			if _, err = r.conn.Write(msg); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			return nil
+		}
+		r.conn.Close()
+		r.conn = nil
+		if attempt > 0 || !r.stream() {
+			return err
+		}
+	}
+}
+
+// close closes the connection.
+func (r *remoteConn) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
@@ -0,0 +1,286 @@
+// Package remotejournal uploads journal entries to [systemd-journal-remote]
+// over HTTPS, like systemd-journal-upload does, for hosts that cannot run
+// the latter. An [Uploader] is meant to be passed in Options.Mirrors:
+//
+//	tlsConfig, err := remotejournal.LoadTLSConfig(
+//		"/etc/ssl/certs/journal-upload.pem",
+//		"/etc/ssl/private/journal-upload.pem",
+//		"/etc/ssl/ca/trusted.pem",
+//	)
+//	...
+//	up := remotejournal.NewUploader("https://logs.example.com:19532", &remotejournal.Options{
+//		TLSConfig: tlsConfig,
+//	})
+//	defer up.Shutdown(context.Background())
+//	h, err := slogjournal.NewHandler(&slogjournal.Options{
+//		Mirrors: []io.Writer{up},
+//	})
+//
+// Entries are sent in the [journal export format] to the /upload endpoint.
+//
+// [systemd-journal-remote]: https://www.freedesktop.org/software/systemd/man/latest/systemd-journal-remote.service.html
+// [journal export format]: https://systemd.io/JOURNAL_EXPORT_FORMATS/
+package remotejournal
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// Options configure an Uploader.
+type Options struct {
+	// TLSConfig configures the TLS connection to the server:
+	// Certificates holds the client certificate journal-remote
+	// deployments usually require, RootCAs the CAs trusted to sign the
+	// server's certificate, and ServerName the name sent for SNI and
+	// verified against the server's certificate. See LoadTLSConfig. It is
+	// ignored if Client is set.
+	TLSConfig *tls.Config
+
+	// Client sends the requests. The default is a client using TLSConfig
+	// with a timeout of ten seconds.
+	Client *http.Client
+
+	// BatchSize is the largest number of entries sent in one request. The
+	// default is 512.
+	BatchSize int
+
+	// FlushInterval is the longest time an entry waits before it is sent.
+	// The default is one second.
+	FlushInterval time.Duration
+
+	// QueueSize is the number of entries waiting to be sent. Further
+	// entries are dropped. The default is 4096.
+	QueueSize int
+}
+
+// LoadTLSConfig returns a TLS configuration for mutual TLS, like the
+// --key, --cert and --trust options of systemd-journal-upload: the client
+// certificate and key are loaded from certFile and keyFile, which may be
+// empty to not send a certificate, and the server's certificate is
+// verified against the PEM encoded CAs in caFile, or the system's CAs if
+// caFile is empty.
+func LoadTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	c := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("remotejournal: %w", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("remotejournal: %w", err)
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("remotejournal: no certificates in %s", caFile)
+		}
+	}
+	return c, nil
+}
+
+// Uploader is an [io.Writer] that sends journal entries to
+// systemd-journal-remote in batches from a background goroutine. Write
+// never blocks; entries arriving while the queue is full are dropped and
+// counted.
+type Uploader struct {
+	url   string
+	opts  Options
+	queue chan []byte
+
+	ctx    context.Context    // context of the requests
+	cancel context.CancelFunc // aborts the requests when Shutdown gives up
+
+	mu      sync.RWMutex // guards closed and sending on queue
+	closed  bool
+	done    chan struct{}
+	dropped int64
+	err     error // last error, guarded by mu
+}
+
+// defaultTimeout limits the requests of the default client.
+const defaultTimeout = 10 * time.Second
+
+// DefaultPort is the port systemd-journal-remote listens on by default.
+const DefaultPort = "19532"
+
+// NewUploader returns an Uploader posting to the systemd-journal-remote
+// server at rawURL, such as https://logs.example.com. As with
+// systemd-journal-upload, the port defaults to 19532 and the path to
+// /upload. If opts is nil, the default options are used.
+func NewUploader(rawURL string, opts *Options) *Uploader {
+	u := &Uploader{url: uploadURL(rawURL), done: make(chan struct{})}
+	u.ctx, u.cancel = context.WithCancel(context.Background())
+	if opts != nil {
+		u.opts = *opts
+	}
+	if u.opts.Client == nil {
+		u.opts.Client = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: u.opts.TLSConfig,
+			},
+			Timeout: defaultTimeout,
+		}
+	}
+	if u.opts.BatchSize <= 0 {
+		u.opts.BatchSize = 512
+	}
+	if u.opts.FlushInterval <= 0 {
+		u.opts.FlushInterval = time.Second
+	}
+	if u.opts.QueueSize <= 0 {
+		u.opts.QueueSize = 4096
+	}
+	u.queue = make(chan []byte, u.opts.QueueSize)
+	go u.run()
+	return u
+}
+
+// uploadURL adds the default port and path to rawURL.
+func uploadURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		// Let the request report the error.
+		return rawURL
+	}
+	if u.Port() == "" {
+		u.Host += ":" + DefaultPort
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/upload"
+	}
+	return u.String()
+}
+
+// Write queues the journal entry p. It returns an error if p is not a
+// valid entry or the uploader was shut down.
+func (u *Uploader) Write(p []byte) (int, error) {
+	if _, err := slogjournal.Decode(p); err != nil {
+		return 0, err
+	}
+	// The native protocol and the export format frame fields the same
+	// way. journal-remote takes the time of an entry from its
+	// __REALTIME_TIMESTAMP field.
+	e := make([]byte, 0, len(p)+48)
+	e = slogjournal.AppendField(e, "__REALTIME_TIMESTAMP", strconv.AppendInt(nil, time.Now().UnixMicro(), 10))
+	e = append(e, p...)
+	e = append(e, '\n')
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return 0, errors.New("remotejournal: uploader is shut down")
+	}
+	select {
+	case u.queue <- e:
+	default:
+		u.dropped++
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of entries dropped because the queue was full.
+func (u *Uploader) Dropped() int64 {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.dropped
+}
+
+// Err returns the error of the last failed request, if any.
+func (u *Uploader) Err() error {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.err
+}
+
+// Shutdown sends the queued entries and stops the uploader. If ctx is done
+// first, it aborts the request in flight, discards the remaining entries
+// and returns ctx's error.
+func (u *Uploader) Shutdown(ctx context.Context) error {
+	u.mu.Lock()
+	if !u.closed {
+		u.closed = true
+		close(u.queue)
+	}
+	u.mu.Unlock()
+	defer u.cancel()
+	select {
+	case <-u.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (u *Uploader) run() {
+	defer close(u.done)
+	t := time.NewTicker(u.opts.FlushInterval)
+	defer t.Stop()
+	var batch bytes.Buffer
+	n := 0
+	for {
+		select {
+		case e, ok := <-u.queue:
+			if !ok {
+				u.send(&batch)
+				return
+			}
+			batch.Write(e)
+			n++
+			if n >= u.opts.BatchSize {
+				u.send(&batch)
+				n = 0
+			}
+		case <-t.C:
+			u.send(&batch)
+			n = 0
+		}
+	}
+}
+
+// send posts the entries in batch and resets it.
+func (u *Uploader) send(batch *bytes.Buffer) {
+	if batch.Len() == 0 {
+		return
+	}
+	err := u.post(batch.Bytes())
+	batch.Reset()
+	u.mu.Lock()
+	u.err = err
+	u.mu.Unlock()
+}
+
+func (u *Uploader) post(body []byte) error {
+	req, err := http.NewRequestWithContext(u.ctx, http.MethodPost, u.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.fdo.journal")
+	resp, err := u.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("remotejournal: %s: %s", u.url, resp.Status)
+	}
+	return nil
+}
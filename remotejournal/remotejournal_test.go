@@ -0,0 +1,248 @@
+package remotejournal
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// testCA issues certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate for name and its key in PEM.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kder, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kder})
+}
+
+func TestUploaderMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca.pem)
+	srvCert, srvKey := ca.issue(t, "logs.example.com", x509.ExtKeyUsageServerAuth)
+	cert, err := tls.X509KeyPair(srvCert, srvKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var bodies [][]byte
+	var clients []string
+	// httptest.NewTLSServer starts before client certificates can be
+	// required, so configure TLS on an unstarted server like it does.
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/upload" || r.Header.Get("Content-Type") != "application/vnd.fdo.journal" {
+			t.Errorf("unexpected request %s %v", r.URL, r.Header)
+		}
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, b)
+		clients = append(clients, r.TLS.PeerCertificates[0].Subject.CommonName)
+		mu.Unlock()
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	// Lay the files out like the package example.
+	dir := t.TempDir()
+	clientCert, clientKey := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	certFile := filepath.Join(dir, "certs", "journal-upload.pem")
+	keyFile := filepath.Join(dir, "private", "journal-upload.pem")
+	caFile := filepath.Join(dir, "ca", "trusted.pem")
+	for name, b := range map[string][]byte{certFile: clientCert, keyFile: clientKey, caFile: ca.pem} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := LoadTLSConfig(keyFile, certFile, caFile); err == nil {
+		t.Error("expected an error with the key passed as the certificate")
+	}
+	tlsConfig, err := LoadTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Connect to the test server by IP, but verify the name the server's
+	// certificate was issued for.
+	tlsConfig.ServerName = "logs.example.com"
+
+	up := NewUploader("https://127.0.0.1:"+port, &Options{TLSConfig: tlsConfig, FlushInterval: time.Hour})
+	h, err := slogjournal.NewHandler(&slogjournal.Options{Mirrors: []io.Writer{up}, Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetLogTarget(slogjournal.LogTargetNull)
+	slog.New(h).Info("hello", "MULTI", "a\nb")
+	slog.New(h).Info("world")
+	if err := up.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := up.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 || clients[0] != "client" {
+		t.Fatalf("got %d requests from %q", len(bodies), clients)
+	}
+	// The export format separates entries by an empty line.
+	first, _, ok := bytes.Cut(bodies[0], []byte("\n\n"))
+	if !ok {
+		t.Fatalf("no entry separator in %q", bodies[0])
+	}
+	fs, err := slogjournal.Decode(append(first, '\n'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var realtime, messages, multi int
+	for _, f := range fs {
+		switch {
+		case f.Key == "__REALTIME_TIMESTAMP":
+			realtime++
+		case f.Key == "MESSAGE":
+			messages++
+		case f.Key == "MULTI" && string(f.Value) == "a\nb":
+			multi++
+		}
+	}
+	if realtime != 1 || messages != 1 || multi != 1 {
+		t.Errorf("unexpected first entry %q", bodies[0])
+	}
+}
+
+func TestUploaderRejectsUntrustedServer(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	tlsConfig, err := LoadTLSConfig("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := NewUploader(srv.URL, &Options{TLSConfig: tlsConfig})
+	if _, err := up.Write([]byte("MESSAGE=hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := up.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if up.Err() == nil {
+		t.Error("expected a certificate error")
+	}
+}
+
+func TestUploaderShutdownAborts(t *testing.T) {
+	stuck := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		close(stuck)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	up := NewUploader(srv.URL, &Options{FlushInterval: time.Millisecond})
+	if _, err := up.Write([]byte("MESSAGE=hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	<-stuck
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := up.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown returned %v", err)
+	}
+	select {
+	case <-up.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request was not aborted")
+	}
+	if up.Err() == nil {
+		t.Error("expected the aborted request to fail")
+	}
+}
+
+func TestUploadURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"https://logs.example.com":              "https://logs.example.com:19532/upload",
+		"https://logs.example.com:443/":         "https://logs.example.com:443/upload",
+		"https://logs.example.com:8080/journal": "https://logs.example.com:8080/journal",
+	} {
+		if got := uploadURL(in); got != want {
+			t.Errorf("uploadURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
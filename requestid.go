@@ -0,0 +1,45 @@
+package slogjournal
+
+import (
+	"context"
+)
+
+// RequestIDKey is the field holding the request ID of contexts returned by
+// [ContextWithRequestID], so that
+//
+//	journalctl REQUEST_ID=...
+//
+// shows everything logged while serving a single request.
+const RequestIDKey = "REQUEST_ID"
+
+var keyRequestID = newKeyInfo(RequestIDKey)
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID id.
+// Records logged with the returned context, or one derived from it, get a
+// REQUEST_ID field, unless they have a REQUEST_ID attribute of their own.
+// See the httpjournal package for middleware assigning request IDs.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID ctx carries, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// requestID returns the request ID to send with a record logged with ctx,
+// or "" if there is none or the record has a REQUEST_ID attribute, as
+// reported by hasAttr.
+func requestID(ctx context.Context, hasAttr bool) string {
+	if hasAttr {
+		return ""
+	}
+	id, _ := RequestIDFromContext(ctx)
+	return id
+}
@@ -0,0 +1,33 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	ew := &entryWriter{}
+	h, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = ew
+	l := slog.New(h)
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	l.InfoContext(ctx, "from context")
+	l.InfoContext(ctx, "own attribute", RequestIDKey, "req-2")
+	l.Info("without")
+
+	for i, want := range []string{"req-1", "req-2", ""} {
+		var ids []string
+		for _, f := range parseFields(ew.entries[i]) {
+			if f.Key == RequestIDKey {
+				ids = append(ids, string(f.Value))
+			}
+		}
+		if want == "" && len(ids) != 0 || want != "" && (len(ids) != 1 || ids[0] != want) {
+			t.Errorf("entry %d: REQUEST_ID %q, want %q", i, ids, want)
+		}
+	}
+}
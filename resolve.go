@@ -0,0 +1,50 @@
+package slogjournal
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// defaultMaxResolveDepth is the default of Options.MaxResolveDepth, the
+// limit slog itself applies to chains of LogValuers.
+const defaultMaxResolveDepth = 100
+
+// resolve resolves v, which is nested in n calls to LogValue, like
+// [slog.Value.Resolve]. It returns the number of LogValue calls the result
+// is nested in and an error if that exceeds Options.MaxResolveDepth.
+func (h *Handler) resolve(v slog.Value, n int) (slog.Value, int, error) {
+	max := h.maxResolveDepth()
+	for v.Kind() == slog.KindLogValuer {
+		if n >= max {
+			return slog.Value{}, n, fmt.Errorf("slogjournal: LogValue of %T nested more than %d levels deep", v.Any(), max)
+		}
+		v = logValue(v.LogValuer())
+		n++
+	}
+	return v, n, nil
+}
+
+// maxResolveDepth returns Options.MaxResolveDepth or its default.
+func (h *Handler) maxResolveDepth() int {
+	if h.opts.MaxResolveDepth > 0 {
+		return h.opts.MaxResolveDepth
+	}
+	return defaultMaxResolveDepth
+}
+
+// logValue calls lv.LogValue, turning a panic into an error value like
+// [slog.Value.Resolve] does.
+func logValue(lv slog.LogValuer) (v slog.Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			v = slog.AnyValue(fmt.Errorf("LogValue panicked: %v", r))
+		}
+	}()
+	return lv.LogValue()
+}
+
+// tooLarge reports whether the fields appended to b since start exceed
+// Options.MaxResolvedBytes.
+func (h *Handler) tooLarge(b []byte, start int) bool {
+	return h.opts.MaxResolvedBytes > 0 && len(b)-start > h.opts.MaxResolvedBytes
+}
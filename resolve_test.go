@@ -0,0 +1,86 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// loop is a LogValuer whose value contains itself.
+type loop struct{}
+
+func (l loop) LogValue() slog.Value {
+	return slog.GroupValue(slog.Any("", l), slog.String("X", "x"))
+}
+
+// big is a LogValuer expanding into many fields.
+type big int
+
+func (n big) LogValue() slog.Value {
+	attrs := make([]slog.Attr, n)
+	for i := range attrs {
+		attrs[i] = slog.String("F", strings.Repeat("x", 100))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func TestResolveLimits(t *testing.T) {
+	ew := &entryWriter{}
+	h, err := NewHandler(&Options{Level: slog.LevelInfo, MaxResolveDepth: 5, MaxResolvedBytes: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = ew
+	l := slog.New(h)
+	l.Info("loop", "LOOP", loop{})
+	l.Info("big", "BIG", big(1000))
+	l.Info("small", "SMALL", big(2))
+
+	count := func(i int, key string) (n int, last string) {
+		for _, f := range parseFields(ew.entries[i]) {
+			if f.Key == key {
+				n++
+				last = string(f.Value)
+			}
+		}
+		return n, last
+	}
+	// The innermost, inlined group has an empty key, so its error is sent
+	// as LOOP_.
+	if n, v := count(0, "LOOP_X"); n != 5 {
+		t.Errorf("got %d LOOP_X fields, want 5", n)
+	} else if n, v = count(0, "LOOP_"); n != 1 || !strings.Contains(v, "nested more than 5 levels") {
+		t.Errorf("LOOP=%q, want depth error", v)
+	}
+	if n, _ := count(1, "BIG_F"); n != 0 {
+		t.Errorf("got %d BIG_F fields, want 0", n)
+	}
+	if _, v := count(1, "BIG"); !strings.Contains(v, "more than 1000 bytes") {
+		t.Errorf("BIG=%q, want size error", v)
+	}
+	if n, _ := count(2, "SMALL_F"); n != 2 {
+		t.Errorf("got %d SMALL_F fields, want 2", n)
+	}
+}
+
+func TestResolvePanic(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	slog.New(h).Info("panic", "P", panicker{})
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(kv["P"], "LogValue panicked") {
+		t.Errorf("P=%q, want panic error", kv["P"])
+	}
+}
+
+type panicker struct{}
+
+func (panicker) LogValue() slog.Value { panic("boom") }
@@ -0,0 +1,60 @@
+package slogjournal
+
+import (
+	"bytes"
+	"sync"
+)
+
+// ring keeps the most recent entries for Options.RecentEntries.
+type ring struct {
+	mu      sync.Mutex
+	entries [][]byte
+	next    int // index of the oldest entry once full
+}
+
+// newRing returns a ring keeping the last n entries, or nil if n is not
+// positive.
+func newRing(n int) *ring {
+	if n <= 0 {
+		return nil
+	}
+	return &ring{entries: make([][]byte, 0, n)}
+}
+
+// add records a copy of the entry b, replacing the oldest entry if the ring
+// is full. The replaced entry's memory is reused.
+func (r *ring) add(b []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) < cap(r.entries) {
+		r.entries = append(r.entries, bytes.Clone(b))
+		return
+	}
+	r.entries[r.next] = append(r.entries[r.next][:0], b...)
+	r.next = (r.next + 1) % len(r.entries)
+}
+
+// snapshot returns copies of the recorded entries, oldest first.
+func (r *ring) snapshot() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([][]byte, 0, len(r.entries))
+	for _, e := range r.entries[r.next:] {
+		out = append(out, bytes.Clone(e))
+	}
+	for _, e := range r.entries[:r.next] {
+		out = append(out, bytes.Clone(e))
+	}
+	return out
+}
+
+// Recent returns the last entries sent by h and the handlers sharing its
+// parent, oldest first, if Options.RecentEntries is set. The entries are
+// in the native protocol format, see [Decode], and belong to the caller.
+// This is meant for crash reports, debug endpoints and support bundles.
+func (h *Handler) Recent() [][]byte {
+	if h.recent == nil {
+		return nil
+	}
+	return h.recent.snapshot()
+}
@@ -0,0 +1,52 @@
+package slogjournal
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+func TestRecent(t *testing.T) {
+	ew := &entryWriter{}
+	h, err := NewHandler(&Options{Level: slog.LevelInfo, RecentEntries: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = ew
+	l := slog.New(h)
+	for i := range 5 {
+		l.With("I", i).Info(fmt.Sprint("entry ", i))
+	}
+	recent := h.Recent()
+	if len(recent) != 3 {
+		t.Fatalf("got %d entries, want 3", len(recent))
+	}
+	for i, e := range recent {
+		fs, err := Decode(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprint("entry ", i+2); string(fs[0].Value) != want {
+			t.Errorf("entry %d: MESSAGE=%q, want %q", i, fs[0].Value, want)
+		}
+	}
+	// Snapshots belong to the caller.
+	recent[0][0] = 'X'
+	if h.Recent()[0][0] != 'M' {
+		t.Error("snapshot aliases the ring")
+	}
+
+	h.SetLogTarget(LogTargetNull)
+	l.Info("discarded")
+	if fs, _ := Decode(h.Recent()[2]); string(fs[0].Value) != "discarded" {
+		t.Errorf("entry for null target not recorded: %q", fs[0].Value)
+	}
+
+	h2, err := NewHandler(&Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h2.Recent() != nil {
+		t.Error("Recent without RecentEntries should be nil")
+	}
+}
@@ -0,0 +1,84 @@
+package slogjournal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Route sends the records in a range of levels to a destination. See
+// Options.Routes.
+type Route struct {
+	// MinLevel is the lowest level sent along the route. If nil, there is
+	// no lower bound.
+	MinLevel slog.Leveler
+
+	// MaxLevel is the highest level sent along the route. If nil, there is
+	// no upper bound.
+	MaxLevel slog.Leveler
+
+	// Target is where the records are sent. The zero value is
+	// LogTargetJournal. Target is ignored if Writer is set.
+	Target LogTarget
+
+	// Writer, if set, receives every record as a single line of text
+	// holding the time, identifier, priority, message and all other fields,
+	// in the format of the fallback on platforms without a journal. It must
+	// be safe for concurrent use.
+	Writer io.Writer
+}
+
+// matches reports whether records at level l are sent along r.
+func (r Route) matches(l slog.Level) bool {
+	if r.MinLevel != nil && l < r.MinLevel.Level() {
+		return false
+	}
+	if r.MaxLevel != nil && l > r.MaxLevel.Level() {
+		return false
+	}
+	return true
+}
+
+// checkRoute reports whether r names a target h can send records to,
+// opening /dev/kmsg if needed.
+func (h *Handler) checkRoute(r Route) error {
+	if r.Writer != nil {
+		return nil
+	}
+	switch r.Target {
+	case "", LogTargetJournal, LogTargetConsole, LogTargetNull:
+		return nil
+	case LogTargetKmsg:
+		_, err := h.targets.openKmsg()
+		return err
+	}
+	return fmt.Errorf("slogjournal: unsupported log target %q", r.Target)
+}
+
+// writeRoutes sends b, logged at level l, along every matching route.
+func (h *Handler) writeRoutes(l slog.Level, b []byte) (int, error) {
+	var total int
+	var errs []error
+	for _, r := range h.opts.Routes {
+		if !r.matches(l) {
+			continue
+		}
+		var n int
+		var err error
+		switch {
+		case r.Writer != nil:
+			tw := textWriter{w: r.Writer}
+			n, err = tw.Write(b)
+		case r.Target == "":
+			n, err = h.writeTo(LogTargetJournal, b)
+		default:
+			n, err = h.writeTo(r.Target, b)
+		}
+		total += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return total, errors.Join(errs...)
+}
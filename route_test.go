@@ -0,0 +1,66 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoutes(t *testing.T) {
+	file := new(bytes.Buffer)
+	journal := new(bytes.Buffer)
+	console := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{
+		Level: slog.LevelDebug,
+		Routes: []Route{
+			{MaxLevel: slog.LevelDebug, Writer: file},
+			{MinLevel: slog.LevelInfo},
+			{MinLevel: LevelCritical, Target: LogTargetConsole},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = journal
+	handler.targets.console = console
+
+	for _, l := range []slog.Level{slog.LevelDebug, slog.LevelInfo, LevelCritical} {
+		r := slog.NewRecord(time.Time{}, l, levelName(l), 0)
+		r.AddAttrs(slog.String("USER", "alice"))
+		_ = handler.Handle(context.TODO(), r)
+	}
+	// The file receives the whole record, not just its message.
+	if line := file.String(); strings.Count(line, "\n") != 1 || !strings.Contains(line, "] debug: DEBUG USER=alice") {
+		t.Errorf("unexpected file output %q", file.Bytes())
+	}
+	var msgs []string
+	for _, f := range parseFields(journal.Bytes()) {
+		if f.Key == "MESSAGE" {
+			msgs = append(msgs, string(f.Value))
+		}
+	}
+	if len(msgs) != 2 || msgs[0] != "INFO" || msgs[1] != "CRITICAL" {
+		t.Errorf("unexpected journal messages %q", msgs)
+	}
+	if console.String() != "CRITICAL\n" {
+		t.Errorf("unexpected console output %q", console.Bytes())
+	}
+
+	// Switching the target overrides the routes.
+	file.Reset()
+	console.Reset()
+	if err := handler.SetLogTarget(LogTargetConsole); err != nil {
+		t.Fatal(err)
+	}
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelDebug, "debug", 0))
+	if file.Len() != 0 || console.String() != "debug\n" {
+		t.Errorf("unexpected output %q, %q", file.Bytes(), console.Bytes())
+	}
+
+	if _, err := NewHandler(&Options{Routes: []Route{{Target: "syslog"}}}); err == nil {
+		t.Error("expected error for unsupported target")
+	}
+}
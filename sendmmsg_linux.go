@@ -0,0 +1,99 @@
+package slogjournal
+
+import (
+	"errors"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmsghdr is struct mmsghdr of sendmmsg(2).
+type mmsghdr struct {
+	hdr unix.Msghdr
+	len uint32
+}
+
+// writeBatch sends entries with as few sendmmsg(2) calls as possible. An
+// entry the kernel refuses, for example because it does not fit in a
+// datagram, is sent with Write instead, which passes it in a memfd or
+// drops it as configured.
+func (j *journalWriter) writeBatch(entries [][]byte, fail func(error)) int {
+	written := 0
+	for len(entries) > 0 {
+		n, err := j.sendmmsg(entries)
+		for _, e := range entries[:n] {
+			written += len(e)
+		}
+		entries = entries[n:]
+		if err == nil || len(entries) == 0 {
+			continue
+		}
+		n, err = j.Write(entries[0])
+		written += n
+		if err != nil {
+			fail(err)
+		}
+		entries = entries[1:]
+	}
+	return written
+}
+
+// sendmmsg sends entries as separate datagrams with a single system call.
+// It returns the number of entries sent, and an error if not all of them
+// were sent. Unless nonblock is set, it waits until the socket has room.
+func (j *journalWriter) sendmmsg(entries [][]byte) (int, error) {
+	rc, err := j.conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var sa unix.RawSockaddrUnix
+	sa.Family = unix.AF_UNIX
+	name := j.addr.Name
+	if len(name) == 0 || len(name) >= len(sa.Path) {
+		return 0, unix.EINVAL
+	}
+	for i := 0; i < len(name); i++ {
+		sa.Path[i] = int8(name[i])
+	}
+	salen := uint32(unsafe.Offsetof(sa.Path)) + uint32(len(name)) + 1
+	if name[0] == '@' {
+		// Abstract addresses are not terminated by a NUL byte.
+		sa.Path[0] = 0
+		salen--
+	}
+
+	iovs := make([]unix.Iovec, len(entries))
+	hdrs := make([]mmsghdr, len(entries))
+	for i, e := range entries {
+		iovs[i].Base = unsafe.SliceData(e)
+		iovs[i].SetLen(len(e))
+		hdrs[i].hdr.Name = (*byte)(unsafe.Pointer(&sa))
+		hdrs[i].hdr.Namelen = salen
+		hdrs[i].hdr.Iov = &iovs[i]
+		hdrs[i].hdr.SetIovlen(1)
+	}
+	flags := 0
+	if j.nonblock {
+		flags = unix.MSG_DONTWAIT
+	}
+	var n int
+	werr := rc.Write(func(fd uintptr) bool {
+		r, _, errno := unix.Syscall6(unix.SYS_SENDMMSG, fd, uintptr(unsafe.Pointer(&hdrs[0])), uintptr(len(hdrs)), uintptr(flags), 0, 0)
+		if errno != 0 {
+			n, err = 0, errno
+		} else {
+			n, err = int(r), nil
+		}
+		// Returning false waits for the socket to become writable.
+		return j.nonblock || !errors.Is(err, unix.EAGAIN)
+	})
+	runtime.KeepAlive(entries)
+	if werr != nil {
+		return 0, werr
+	}
+	if err == nil && n < len(entries) {
+		err = unix.EAGAIN
+	}
+	return n, err
+}
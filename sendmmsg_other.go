@@ -0,0 +1,9 @@
+//go:build !linux
+
+package slogjournal
+
+// writeBatch sends entries one at a time, as sendmmsg(2) is only available
+// on Linux.
+func (j *journalWriter) writeBatch(entries [][]byte, fail func(error)) int {
+	return writeEach(j, entries, fail)
+}
@@ -0,0 +1,107 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// LevelSetter is a [slog.Leveler] whose level can be changed, such as
+// [LevelVar] or [slog.LevelVar].
+type LevelSetter interface {
+	slog.Leveler
+	Set(slog.Level)
+}
+
+// namedLevels lists the named levels from most to least verbose.
+var namedLevels = []slog.Level{
+	slog.LevelDebug,
+	slog.LevelInfo,
+	LevelNotice,
+	slog.LevelWarn,
+	slog.LevelError,
+	LevelCritical,
+	LevelAlert,
+	LevelEmergency,
+}
+
+// NotifyLevelSignals changes the level of l at runtime, like many systemd
+// daemons do: SIGUSR1 lowers the level to the next more verbose named level
+// and SIGUSR2 raises it to the next less verbose one. Every change is logged
+// to logger at LevelNotice; if logger is nil, [slog.Default] is used.
+//
+// To control the level of a [Handler], pass the same l as Options.Level.
+// The returned function stops handling the signals.
+func NotifyLevelSignals(l LevelSetter, logger *slog.Logger) (stop func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1, syscall.SIGUSR2)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-c:
+				old := l.Level()
+				level := stepLevel(old, sig == syscall.SIGUSR1)
+				l.Set(level)
+				log := logger
+				if log == nil {
+					log = slog.Default()
+				}
+				log.Log(context.Background(), LevelNotice, "Log level changed",
+					"SIGNAL", sig.String(), "OLD_LEVEL", levelName(old), "NEW_LEVEL", levelName(level))
+			}
+		}
+	}()
+	return sync.OnceFunc(func() {
+		signal.Stop(c)
+		close(done)
+	})
+}
+
+// stepLevel returns the named level next to l, towards more verbose levels if
+// verbose is set. It stays within the range of named levels.
+func stepLevel(l slog.Level, verbose bool) slog.Level {
+	if verbose {
+		for i := len(namedLevels) - 1; i >= 0; i-- {
+			if namedLevels[i] < l {
+				return namedLevels[i]
+			}
+		}
+		return namedLevels[0]
+	}
+	for _, n := range namedLevels {
+		if n > l {
+			return n
+		}
+	}
+	return namedLevels[len(namedLevels)-1]
+}
+
+// ReloadOnSIGHUP makes v reload its level from the environment whenever the
+// process receives SIGHUP. The returned function stops handling the signal.
+func (v *LevelVar) ReloadOnSIGHUP() (stop func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-c:
+				v.Reload()
+			}
+		}
+	}()
+	return sync.OnceFunc(func() {
+		signal.Stop(c)
+		close(done)
+	})
+}
@@ -0,0 +1,67 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStepLevel(t *testing.T) {
+	if l := stepLevel(slog.LevelInfo, true); l != slog.LevelDebug {
+		t.Errorf("got %v, want DEBUG", l)
+	}
+	if l := stepLevel(slog.LevelDebug, true); l != slog.LevelDebug {
+		t.Errorf("got %v, want DEBUG", l)
+	}
+	if l := stepLevel(slog.LevelInfo, false); l != LevelNotice {
+		t.Errorf("got %v, want NOTICE", l)
+	}
+	if l := stepLevel(slog.LevelWarn+1, false); l != slog.LevelError {
+		t.Errorf("got %v, want ERROR", l)
+	}
+	if l := stepLevel(LevelEmergency, false); l != LevelEmergency {
+		t.Errorf("got %v, want EMERGENCY", l)
+	}
+}
+
+func TestNotifyLevelSignals(t *testing.T) {
+	var lv slog.LevelVar
+	var buf bytes.Buffer
+	stop := NotifyLevelSignals(&lv, slog.New(slog.NewTextHandler(&buf, nil)))
+	defer stop()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for lv.Level() != slog.LevelDebug {
+		if time.Now().After(deadline) {
+			t.Fatal("level was not changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloadOnSIGHUP(t *testing.T) {
+	t.Setenv("DEBUG_INVOCATION", "")
+	t.Setenv("SYSTEMD_LOG_LEVEL", "info")
+	v := NewLevelVar()
+	stop := v.ReloadOnSIGHUP()
+	defer stop()
+
+	t.Setenv("SYSTEMD_LOG_LEVEL", "alert")
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for v.Level() != LevelAlert {
+		if time.Now().After(deadline) {
+			t.Fatal("level was not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
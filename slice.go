@@ -0,0 +1,61 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"reflect"
+	"strconv"
+)
+
+// SliceEncoding selects how slice and array attribute values are sent.
+type SliceEncoding int
+
+const (
+	// SliceString sends the value's string form, such as "[a b c]", in a
+	// single field.
+	SliceString SliceEncoding = iota
+	// SliceJSON sends the value as a JSON array in a single field, such as
+	// KEY=["a","b","c"].
+	SliceJSON
+	// SliceIndexed sends every element in a field of its own, numbered from
+	// zero: KEY_0=a, KEY_1=b and KEY_2=c. Elements are encoded like any
+	// other attribute value, so slices of structs with journal tags become
+	// groups of fields.
+	SliceIndexed
+)
+
+// sliceValue reports whether v holds a slice or array. Byte slices are
+// left alone, since neither encoding suits binary data.
+func sliceValue(v slog.Value) (reflect.Value, bool) {
+	if v.Kind() != slog.KindAny {
+		return reflect.Value{}, false
+	}
+	x := v.Any()
+	if _, ok := x.([]byte); ok {
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(x)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return rv, true
+	}
+	return reflect.Value{}, false
+}
+
+// encodeSlice rewrites the slice or array value v according to e.
+func encodeSlice(v slog.Value, e SliceEncoding) slog.Value {
+	rv, ok := sliceValue(v)
+	if !ok {
+		return v
+	}
+	switch e {
+	case SliceJSON:
+		return slog.StringValue(string(appendJSONValue(nil, v)))
+	case SliceIndexed:
+		attrs := make([]slog.Attr, rv.Len())
+		for i := range attrs {
+			attrs[i] = slog.Any(strconv.Itoa(i), rv.Index(i).Interface())
+		}
+		return slog.GroupValue(attrs...)
+	}
+	return v
+}
@@ -0,0 +1,43 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestSliceEncoding(t *testing.T) {
+	type user struct {
+		Name string `journal:"NAME"`
+	}
+	for _, tt := range []struct {
+		enc  SliceEncoding
+		want map[string]string
+	}{
+		{SliceString, map[string]string{"TAGS": "[a b c]", "IDS": "[1 2]", "DATA": "[1 2]"}},
+		{SliceJSON, map[string]string{"TAGS": `["a","b","c"]`, "IDS": "[1,2]", "USERS": `[{"Name":"bob"}]`, "DATA": "[1 2]"}},
+		{SliceIndexed, map[string]string{"TAGS_0": "a", "TAGS_1": "b", "TAGS_2": "c", "IDS_0": "1", "IDS_1": "2", "USERS_0_NAME": "bob", "DATA": "[1 2]"}},
+	} {
+		buf := new(bytes.Buffer)
+		h, err := NewHandler(&Options{Level: slog.LevelInfo, Slices: tt.enc})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.w = buf
+		slog.New(h).Info("hello",
+			"TAGS", []string{"a", "b", "c"},
+			"IDS", [2]int{1, 2},
+			"USERS", []user{{"bob"}},
+			"DATA", []byte{1, 2},
+		)
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range tt.want {
+			if kv[k] != v {
+				t.Errorf("encoding %d: %s=%q, want %q", tt.enc, k, kv[k], v)
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package slogjournal
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// forceSendBuffer sets the send buffer of conn to size bytes with
+// SO_SNDBUFFORCE, which ignores the net.core.wmem_max limit but requires
+// CAP_NET_ADMIN.
+func forceSendBuffer(conn *net.UnixConn, size int) error {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	if err := rc.Control(func(fd uintptr) {
+		serr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_SNDBUFFORCE, size)
+	}); err != nil {
+		return err
+	}
+	return serr
+}
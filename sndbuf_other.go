@@ -0,0 +1,12 @@
+//go:build !linux
+
+package slogjournal
+
+import (
+	"errors"
+	"net"
+)
+
+func forceSendBuffer(*net.UnixConn, int) error {
+	return errors.ErrUnsupported
+}
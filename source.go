@@ -0,0 +1,51 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"strconv"
+)
+
+// sourceOf returns the source location held by v, which wrappers that
+// capture the caller themselves attach as a *slog.Source or slog.Source
+// attribute instead of setting the record's PC.
+func sourceOf(v slog.Value) (*slog.Source, bool) {
+	if v.Kind() != slog.KindAny {
+		return nil, false
+	}
+	switch s := v.Any().(type) {
+	case *slog.Source:
+		return s, s != nil
+	case slog.Source:
+		return &s, true
+	}
+	return nil, false
+}
+
+// hasSource reports whether a, or an attribute in the group a, holds a
+// source location. LogValuers are not resolved, since doing so is left to
+// appendAttr with its limits.
+func hasSource(a slog.Attr) bool {
+	v := a.Value
+	if v.Kind() == slog.KindGroup {
+		for _, a := range v.Group() {
+			if hasSource(a) {
+				return true
+			}
+		}
+		return false
+	}
+	_, ok := sourceOf(v)
+	return ok
+}
+
+// appendSource appends the CODE_FILE, CODE_FUNC and CODE_LINE fields for s
+// to b, unless Options.OmitCode is set.
+func (h *Handler) appendSource(b []byte, s *slog.Source) []byte {
+	if h.opts.OmitCode {
+		return b
+	}
+	var num [20]byte
+	b = h.appendValue(b, keyCodeFile, []byte(s.File))
+	b = h.appendValue(b, keyCodeFunc, []byte(s.Function))
+	return h.appendValue(b, keyCodeLine, strconv.AppendInt(num[:0], int64(s.Line), 10))
+}
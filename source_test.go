@@ -0,0 +1,82 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSourceAttr(t *testing.T) {
+	src := &slog.Source{Function: "main.wrapped", File: "/src/main.go", Line: 42}
+	pc, _, _, _ := runtime.Caller(0)
+	for _, tt := range []struct {
+		name string
+		log  func(h slog.Handler) error
+	}{
+		{"pointer", func(h slog.Handler) error {
+			r := slog.NewRecord(time.Now(), slog.LevelInfo, "hello", pc)
+			r.AddAttrs(slog.Any(slog.SourceKey, src))
+			return h.Handle(context.TODO(), r)
+		}},
+		{"value", func(h slog.Handler) error {
+			r := slog.NewRecord(time.Now(), slog.LevelInfo, "hello", 0)
+			r.AddAttrs(slog.Any("caller", *src))
+			return h.Handle(context.TODO(), r)
+		}},
+		{"with", func(h slog.Handler) error {
+			r := slog.NewRecord(time.Now(), slog.LevelInfo, "hello", pc)
+			return h.WithAttrs([]slog.Attr{slog.Any(slog.SourceKey, src)}).Handle(context.TODO(), r)
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ew := &entryWriter{}
+			h, err := NewHandler(&Options{Level: slog.LevelInfo})
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.w = ew
+			if err := tt.log(h); err != nil {
+				t.Fatal(err)
+			}
+			fs := parseFields(ew.entries[0])
+			want := map[string]string{"CODE_FILE": "/src/main.go", "CODE_FUNC": "main.wrapped", "CODE_LINE": "42"}
+			n := 0
+			for _, f := range fs {
+				switch f.Key {
+				case "CODE_FILE", "CODE_FUNC", "CODE_LINE":
+					n++
+					if string(f.Value) != want[f.Key] {
+						t.Errorf("%s=%q, want %q", f.Key, f.Value, want[f.Key])
+					}
+				case "SOURCE", "CALLER":
+					t.Errorf("unexpected field %s=%q", f.Key, f.Value)
+				}
+			}
+			if n != 3 {
+				t.Errorf("got %d CODE_* fields, want 3", n)
+			}
+		})
+	}
+}
+
+func TestSourceAttrOmitCode(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{Level: slog.LevelInfo, OmitCode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	slog.New(h).Info("hello", slog.Any(slog.SourceKey, &slog.Source{File: "x.go", Line: 1}))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"CODE_FILE", "CODE_LINE", "SOURCE"} {
+		if _, ok := kv[k]; ok {
+			t.Errorf("unexpected field %s", k)
+		}
+	}
+}
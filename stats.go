@@ -0,0 +1,100 @@
+package slogjournal
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are counters describing the work done by a [Handler] and all
+// handlers derived from it.
+type Stats struct {
+	// Records is the number of records passed to Handle.
+	Records uint64 `json:"records"`
+	// Bytes is the number of bytes written, including the payload of
+	// entries sent through a memfd. With asynchronous delivery, entries
+	// are counted once they have been sent.
+	Bytes uint64 `json:"bytes"`
+	// MemfdFallbacks is the number of entries that did not fit in a single
+	// datagram and were sent through a memfd instead.
+	MemfdFallbacks uint64 `json:"memfd_fallbacks"`
+	// Dropped is the number of entries that were silently discarded, for
+	// example because journald was not running, the queue was full or the
+	// rate limit suppressed them.
+	Dropped uint64 `json:"dropped"`
+	// WriteErrors is the number of entries whose delivery failed with an
+	// error returned from Handle.
+	WriteErrors uint64 `json:"write_errors"`
+}
+
+// stats holds the counters behind Stats.
+type stats struct {
+	records atomic.Uint64
+	bytes   atomic.Uint64
+	memfds  atomic.Uint64
+	dropped atomic.Uint64
+	errors  atomic.Uint64
+
+	lastError atomic.Pointer[deliveryError]
+}
+
+// deliveryError is a failure to deliver an entry and when it happened.
+type deliveryError struct {
+	err error
+	at  time.Time
+}
+
+// recordError counts err as a write error and remembers it as the last one.
+func (s *stats) recordError(err error) {
+	s.errors.Add(1)
+	s.setLastError(err)
+}
+
+// setLastError remembers err as the last delivery error without counting it.
+func (s *stats) setLastError(err error) {
+	s.lastError.Store(&deliveryError{err: err, at: time.Now()})
+}
+
+// Stats returns a snapshot of h's counters. Each counter is read
+// atomically, but the counters are not read at the same instant.
+func (h *Handler) Stats() Stats {
+	if h.stats == nil {
+		return Stats{}
+	}
+	return Stats{
+		Records:        h.stats.records.Load(),
+		Bytes:          h.stats.bytes.Load(),
+		MemfdFallbacks: h.stats.memfds.Load(),
+		Dropped:        h.stats.dropped.Load(),
+		WriteErrors:    h.stats.errors.Load(),
+	}
+}
+
+// PublishExpvar publishes h's statistics with [expvar] under name, or
+// "slogjournal" if name is empty, so they appear on /debug/vars. The value
+// is a JSON object with the keys records, bytes, memfd_fallbacks, dropped
+// and write_errors. Like [expvar.Publish], it panics if name is already in
+// use.
+func (h *Handler) PublishExpvar(name string) {
+	if name == "" {
+		name = "slogjournal"
+	}
+	expvar.Publish(name, expvar.Func(func() any {
+		return h.Stats()
+	}))
+}
+
+// LastError returns the most recent error h or a handler derived from it
+// failed to deliver an entry with, and when that happened. It returns a nil
+// error if delivery never failed. Health checks can use it to report
+// degraded logging separately from application errors.
+func (h *Handler) LastError() (error, time.Time) {
+	if h.stats == nil {
+		return nil, time.Time{}
+	}
+	e := h.stats.lastError.Load()
+	if e == nil {
+		return nil, time.Time{}
+	}
+	return e.err, e.at
+}
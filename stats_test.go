@@ -0,0 +1,59 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestPublishExpvar(t *testing.T) {
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = failingWriter{}
+	h.PublishExpvar("slogjournal_test")
+	_ = h.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "error", 0))
+
+	var got map[string]uint64
+	if err := json.Unmarshal([]byte(expvar.Get("slogjournal_test").String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["records"] != 1 || got["write_errors"] != 1 {
+		t.Errorf("unexpected expvar value %v", got)
+	}
+}
+
+func TestLastError(t *testing.T) {
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = new(bytes.Buffer)
+	if err, at := h.LastError(); err != nil || !at.IsZero() {
+		t.Errorf("expected no error, got %v at %v", err, at)
+	}
+	_ = h.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "ok", 0))
+
+	before := time.Now()
+	h.w = failingWriter{}
+	_ = h.WithGroup("G").Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "error", 0))
+	err, at := h.LastError()
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if at.Before(before) {
+		t.Errorf("unexpected time %v", at)
+	}
+}
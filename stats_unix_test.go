@@ -0,0 +1,60 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	h, err := NewHandler(&Options{Addr: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := h.WithGroup("G")
+	_ = child.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "small", 0))
+	_ = h.w.(*journalWriter).conn.SetWriteBuffer(1024)
+	_ = h.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, strings.Repeat("a", 64*1024), 0))
+
+	s := h.Stats()
+	if s.Records != 2 || s.MemfdFallbacks != 1 || s.Dropped != 0 || s.WriteErrors != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if s.Bytes < 64*1024 {
+		t.Errorf("expected at least 64KiB, got %d", s.Bytes)
+	}
+
+	missing, err := NewHandler(&Options{Addr: filepath.Join(t.TempDir(), "missing")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = missing.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "lost", 0))
+	if s := missing.Stats(); s.Dropped != 1 {
+		t.Errorf("expected a dropped entry, got %+v", s)
+	}
+
+	failing, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing.w = failingWriter{}
+	if err := failing.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "error", 0)); err == nil {
+		t.Error("expected an error")
+	}
+	if s := failing.Stats(); s.WriteErrors != 1 {
+		t.Errorf("expected a write error, got %+v", s)
+	}
+}
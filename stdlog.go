@@ -0,0 +1,43 @@
+package slogjournal
+
+import (
+	"log"
+	"log/slog"
+)
+
+// WithIdentifier returns a new Handler that sends identifier as the
+// SYSLOG_IDENTIFIER field instead of the receiver's identifier.
+func (h *Handler) WithIdentifier(identifier string) *Handler {
+	h2 := *h
+	h2.identifier = []byte(identifier)
+	return &h2
+}
+
+// NewLogLogger returns a [log.Logger] that sends every message to h as a
+// record at level, for code that has not been migrated to slog yet. The
+// CODE_* fields point at the caller of the log.Logger method. If identifier
+// is not empty, it is used as SYSLOG_IDENTIFIER for these records.
+func NewLogLogger(h *Handler, level slog.Level, identifier string) *log.Logger {
+	if identifier != "" {
+		h = h.WithIdentifier(identifier)
+	}
+	return slog.NewLogLogger(h, level)
+}
+
+// RedirectStdLog makes the top-level functions of the log package, such as
+// [log.Printf], send their messages to h like a logger returned by
+// [NewLogLogger]. Unlike [slog.SetDefault], it leaves the default slog
+// logger alone. The returned function restores the previous output, flags
+// and prefix of the log package.
+func RedirectStdLog(h *Handler, level slog.Level, identifier string) (restore func()) {
+	l := NewLogLogger(h, level, identifier)
+	out, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+	log.SetOutput(l.Writer())
+	log.SetFlags(0)
+	log.SetPrefix("")
+	return func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}
+}
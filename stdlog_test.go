@@ -0,0 +1,56 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewLogLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+
+	l := NewLogLogger(h, slog.LevelWarn, "legacy")
+	l.Printf("hello %s", "world")
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["MESSAGE"] != "hello world" || kv["PRIORITY"] != "4" || kv["SYSLOG_IDENTIFIER"] != "legacy" {
+		t.Error("unexpected fields", kv)
+	}
+	if !strings.HasSuffix(kv["CODE_FILE"], "stdlog_test.go") {
+		t.Error("expected source of the caller", kv["CODE_FILE"])
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+
+	flags := log.Flags()
+	restore := RedirectStdLog(h, slog.LevelInfo, "")
+	log.Print("via std")
+	restore()
+
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["MESSAGE"] != "via std" || !strings.HasSuffix(kv["CODE_FILE"], "stdlog_test.go") {
+		t.Error("unexpected fields", kv)
+	}
+	if log.Flags() != flags {
+		t.Error("expected flags to be restored")
+	}
+}
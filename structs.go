@@ -0,0 +1,98 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structField describes how a struct field is sent to the journal.
+type structField struct {
+	index     int
+	name      string
+	omitempty bool
+}
+
+// structInfo is the cached result of inspecting a struct type.
+type structInfo struct {
+	fields []structField
+	// tagged reports whether any field has a journal tag.
+	tagged bool
+}
+
+var structCache sync.Map // reflect.Type -> *structInfo
+
+// typeInfo returns the fields of the struct type t that are sent to the
+// journal. A field tagged `journal:"NAME"` is sent as NAME, a field tagged
+// `journal:"-"` is skipped, and the option omitempty skips zero values.
+// Other exported fields are named by fieldName.
+func typeInfo(t reflect.Type) *structInfo {
+	if info, ok := structCache.Load(t); ok {
+		return info.(*structInfo)
+	}
+	info := &structInfo{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		sf := structField{index: i, name: fieldName(f.Name)}
+		if tag, ok := f.Tag.Lookup("journal"); ok {
+			info.tagged = true
+			name, opts, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if name != "" {
+				sf.name = name
+			}
+			sf.omitempty = opts == "omitempty"
+		}
+		info.fields = append(info.fields, sf)
+	}
+	structCache.Store(t, info)
+	return info
+}
+
+// structAttrs returns the fields of the struct, or pointer to struct, v as
+// attributes. With taggedOnly set, it reports false for structs without any
+// journal tag.
+func structAttrs(v any, taggedOnly bool) ([]slog.Attr, bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+	info := typeInfo(rv.Type())
+	if taggedOnly && !info.tagged {
+		return nil, false
+	}
+	attrs := make([]slog.Attr, 0, len(info.fields))
+	for _, f := range info.fields {
+		fv := rv.Field(f.index)
+		if f.omitempty && fv.IsZero() {
+			continue
+		}
+		attrs = append(attrs, slog.Any(f.name, fv.Interface()))
+	}
+	return attrs, true
+}
+
+// expandAttrs returns the fields of a tagged struct, or of a map if
+// Options.ExpandMaps is set, as attributes. It reports false once v is
+// nested in Options.MaxResolveDepth expansions or LogValue calls.
+func (h *Handler) expandAttrs(v any, n int) ([]slog.Attr, bool) {
+	if n >= h.maxResolveDepth() {
+		return nil, false
+	}
+	if attrs, ok := structAttrs(v, true); ok {
+		return attrs, true
+	}
+	return h.mapAttrs(v)
+}
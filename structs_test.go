@@ -0,0 +1,89 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type taggedRequest struct {
+	Method    string `journal:"METHOD"`
+	Path      string `journal:"PATH,omitempty"`
+	Body      []byte `journal:"-"`
+	RemoteIP  string
+	unexposed int
+}
+
+func TestStructTags(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	logger := slog.New(h)
+
+	logger.Info("request", "REQ", &taggedRequest{Method: "GET", Body: []byte("secret"), RemoteIP: "::1"})
+	m, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["REQ_METHOD"] != "GET" || m["REQ_REMOTE_IP"] != "::1" {
+		t.Errorf("unexpected fields %v", m)
+	}
+	for _, k := range []string{"REQ", "REQ_PATH", "REQ_BODY", "REQ_UNEXPOSED"} {
+		if _, ok := m[k]; ok {
+			t.Errorf("unexpected field %s in %v", k, m)
+		}
+	}
+
+	buf.Reset()
+	logger.Info("request", slog.Any("", taggedRequest{Method: "PUT", Path: "/"}))
+	m, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["METHOD"] != "PUT" || m["PATH"] != "/" {
+		t.Errorf("unexpected fields %v", m)
+	}
+
+	// Structs without tags are unchanged.
+	buf.Reset()
+	logger.Info("plain", "POINT", struct{ X, Y int }{1, 2})
+	m, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["POINT"] != "{1 2}" {
+		t.Errorf("unexpected fields %v", m)
+	}
+}
+
+type taggedNode struct {
+	Name string      `journal:"N"`
+	Next *taggedNode `journal:"X"`
+}
+
+func TestStructCycle(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{Level: slog.LevelInfo, MaxResolveDepth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	n := &taggedNode{Name: "loop"}
+	n.Next = n
+	slog.New(h).Info("cycle", "NODE", n)
+	m, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["NODE_N"] != "loop" || m["NODE_X_X_N"] != "loop" {
+		t.Errorf("unexpected fields %v", m)
+	}
+	// Past the limit the value is formatted with fmt.
+	if v := m["NODE_X_X_X"]; !strings.HasPrefix(v, "&{loop 0x") {
+		t.Errorf("unexpected NODE_X_X_X %q", v)
+	}
+}
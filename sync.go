@@ -0,0 +1,37 @@
+package slogjournal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sync asks journald to write all entries it has received so far to disk and
+// waits until it has done so, like journalctl --sync. Crash-sensitive code
+// can call it to make sure earlier records are persisted before proceeding.
+// With several journals, see Options.ExtraNamespaces, each of them is
+// synced. With asynchronous delivery, see Options.QueueSize, Sync first
+// waits until the entries queued so far have been sent. Sync does nothing if
+// h does not send records to the journal.
+func (h *Handler) Sync() error {
+	if h.LogTarget() != LogTargetJournal {
+		return nil
+	}
+	if a, ok := h.w.(*asyncWriter); ok {
+		if err := a.waitSent(context.Background()); err != nil {
+			return err
+		}
+	}
+	var errs []error
+	for _, w := range journalWriters(h.w) {
+		if strings.HasPrefix(w.addr.Name, "@") {
+			errs = append(errs, fmt.Errorf("slogjournal: cannot sync the journal at abstract address %q: %w", w.addr.Name, errors.ErrUnsupported))
+			continue
+		}
+		if err := (&JournalControl{path: varlinkSocket(w.addr.Name)}).Synchronize(context.Background()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
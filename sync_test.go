@@ -0,0 +1,143 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// listenVarlink serves the varlink socket of a journal in dir, calling
+// handle with the method of every call before replying to it.
+func listenVarlink(t *testing.T, dir string, handle func(method string)) net.Listener {
+	t.Helper()
+	l, err := net.Listen("unix", filepath.Join(dir, "io.systemd.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			b, err := bufio.NewReader(conn).ReadBytes(0)
+			if err == nil {
+				var req struct{ Method string }
+				_ = json.Unmarshal(b[:len(b)-1], &req)
+				handle(req.Method)
+				_, _ = conn.Write([]byte("{}\x00"))
+			}
+			conn.Close()
+		}
+	}()
+	return l
+}
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	methods := make(chan string, 1)
+	l := listenVarlink(t, dir, func(method string) { methods <- method })
+
+	h, err := NewHandler(&Options{Addr: filepath.Join(dir, "socket")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if m := <-methods; m != "io.systemd.Journal.Synchronize" {
+		t.Errorf("unexpected method %q", m)
+	}
+
+	l.Close()
+	if err := h.Sync(); err == nil {
+		t.Error("expected an error without a varlink service")
+	}
+	if err := h.SetLogTarget(LogTargetNull); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Sync(); err != nil {
+		t.Errorf("expected no error for the null target, got %v", err)
+	}
+}
+
+func TestSyncQueued(t *testing.T) {
+	dir := t.TempDir()
+	addr := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Like journald, read entries continuously and report how many were
+	// received when Synchronize is called, allowing for the reader to be
+	// a little behind the socket.
+	const n = 100
+	var count atomic.Int64
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				return
+			}
+			count.Add(1)
+		}
+	}()
+	received := make(chan int64, 1)
+	listenVarlink(t, dir, func(string) {
+		for deadline := time.Now().Add(time.Second); count.Load() < n && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+		received <- count.Load()
+	})
+
+	h, err := NewHandler(&Options{Addr: addr, Level: slog.LevelInfo, QueueSize: 2 * n})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Shutdown(context.Background())
+	for i := range n {
+		slog.New(h).Info("queued", "I", i)
+	}
+	if err := h.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if p := h.w.(*asyncWriter).pending.Load(); p != 0 {
+		t.Errorf("%d entries still pending after Sync", p)
+	}
+	if got := <-received; got != n {
+		t.Errorf("journal received %d entries before Synchronize, want %d", got, n)
+	}
+}
+
+func TestSyncWaitsForQueue(t *testing.T) {
+	w := &gatedWriter{gate: make(chan struct{})}
+	h := newAsyncHandler(t, w, 10)
+	slog.New(h).Info("one")
+	slog.New(h).Info("two")
+
+	synced := make(chan error, 1)
+	go func() { synced <- h.Sync() }()
+	select {
+	case err := <-synced:
+		t.Fatalf("Sync returned %v before the queue was sent", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(w.gate)
+	if err := <-synced; err != nil {
+		t.Fatal(err)
+	}
+	if n := w.entries(); n != 2 {
+		t.Errorf("got %d entries after Sync, want 2", n)
+	}
+}
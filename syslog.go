@@ -0,0 +1,202 @@
+package slogjournal
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyslogFormat selects the message format of a syslog transport.
+type SyslogFormat int
+
+const (
+	// RFC5424 formats messages according to RFC 5424. Fields other than
+	// MESSAGE, PRIORITY, SYSLOG_IDENTIFIER and MESSAGE_ID are sent as
+	// SD-PARAMs of the structured data element "journal@32473".
+	RFC5424 SyslogFormat = iota
+	// RFC3164 formats messages in the traditional BSD format. Fields are
+	// appended to the message as KEY=value pairs.
+	RFC3164
+)
+
+// sdID is the SD-ID of the structured data element carrying the fields. 32473
+// is the private enterprise number reserved for documentation by RFC 5612.
+const sdID = "journal@32473"
+
+// userFacility is the syslog facility used unless SYSLOG_FACILITY is set.
+const userFacility = 1
+
+// syslogWriter sends every entry as a single syslog message to w.
+type syslogWriter struct {
+	w        io.Writer
+	format   SyslogFormat
+	hostname string
+	now      func() time.Time
+}
+
+// newSyslogWriter returns a syslogWriter sending messages in format f to w.
+func newSyslogWriter(w io.Writer, f SyslogFormat) *syslogWriter {
+	hostname, _ := os.Hostname()
+	return &syslogWriter{w: w, format: f, hostname: hostname}
+}
+
+// Write decodes the entry p and writes it to w as a single message.
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	if _, err := s.w.Write(s.append(nil, parseFields(p), now())); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// append appends the fields fs as a message in s.format.
+func (s *syslogWriter) append(b []byte, fs []Field, t time.Time) []byte {
+	if s.format == RFC3164 {
+		return appendRFC3164(b, fs, t)
+	}
+	return appendRFC5424(b, fs, s.hostname, t)
+}
+
+// syslogHeader holds the fields of an entry that make up the syslog header.
+type syslogHeader struct {
+	pri     int
+	time    time.Time
+	ident   string
+	msgID   string
+	message []byte
+	rest    []Field
+}
+
+// splitSyslogHeader separates the header fields of fs from the others. The
+// time is t unless fs carries a SYSLOG_TIMESTAMP.
+func splitSyslogHeader(fs []Field, t time.Time) syslogHeader {
+	h := syslogHeader{time: t, ident: string(identifier)}
+	prio, facility := int(logInfo), userFacility
+	for _, f := range fs {
+		switch f.Key {
+		case "MESSAGE":
+			h.message = f.Value
+		case "PRIORITY":
+			if n, err := strconv.Atoi(string(f.Value)); err == nil && n >= 0 && n < 8 {
+				prio = n
+			}
+		case "SYSLOG_FACILITY":
+			if n, err := strconv.Atoi(string(f.Value)); err == nil && n >= 0 && n < 24 {
+				facility = n
+			}
+		case "SYSLOG_IDENTIFIER":
+			h.ident = string(f.Value)
+		case "SYSLOG_TIMESTAMP":
+			if t, ok := ParseSyslogTimestamp(string(f.Value)); ok {
+				h.time = t
+			}
+		case "MESSAGE_ID":
+			h.msgID = string(f.Value)
+		default:
+			h.rest = append(h.rest, f)
+		}
+	}
+	h.pri = facility<<3 | prio
+	return h
+}
+
+// appendRFC5424 appends the fields fs as an RFC 5424 message.
+func appendRFC5424(b []byte, fs []Field, hostname string, t time.Time) []byte {
+	h := splitSyslogHeader(fs, t)
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(h.pri), 10)
+	b = append(b, ">1 "...)
+	b = h.time.AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+	b = append(b, ' ')
+	b = appendHeaderField(b, hostname, 255)
+	b = append(b, ' ')
+	b = appendHeaderField(b, h.ident, 48)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(os.Getpid()), 10)
+	b = append(b, ' ')
+	b = appendHeaderField(b, h.msgID, 32)
+	b = append(b, ' ')
+	b = appendStructuredData(b, h.rest)
+	if len(h.message) > 0 {
+		b = append(b, ' ')
+		b = append(b, strings.ToValidUTF8(string(h.message), "�")...)
+	}
+	return b
+}
+
+// appendHeaderField appends s as an RFC 5424 header field of at most max
+// printable ASCII characters, or the NILVALUE if s is empty.
+func appendHeaderField(b []byte, s string, max int) []byte {
+	start := len(b)
+	for i := 0; i < len(s) && len(b)-start < max; i++ {
+		if s[i] > ' ' && s[i] < 0x7f {
+			b = append(b, s[i])
+		}
+	}
+	if len(b) == start {
+		b = append(b, '-')
+	}
+	return b
+}
+
+// appendStructuredData appends fs as SD-PARAMs of a single structured data
+// element, or the NILVALUE if there are none. Keys longer than the 32
+// characters allowed for a PARAM-NAME are dropped.
+func appendStructuredData(b []byte, fs []Field) []byte {
+	n := 0
+	for _, f := range fs {
+		if len(f.Key) == 0 || len(f.Key) > 32 {
+			continue
+		}
+		if n == 0 {
+			b = append(b, '[')
+			b = append(b, sdID...)
+		}
+		n++
+		b = append(b, ' ')
+		b = append(b, f.Key...)
+		b = append(b, `="`...)
+		for _, r := range strings.ToValidUTF8(string(f.Value), "�") {
+			if r == '"' || r == '\\' || r == ']' {
+				b = append(b, '\\')
+			}
+			b = append(b, string(r)...)
+		}
+		b = append(b, '"')
+	}
+	if n == 0 {
+		return append(b, '-')
+	}
+	return append(b, ']')
+}
+
+// appendRFC3164 appends the fields fs as a traditional BSD syslog message.
+func appendRFC3164(b []byte, fs []Field, t time.Time) []byte {
+	h := splitSyslogHeader(fs, t)
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(h.pri), 10)
+	b = append(b, '>')
+	b = h.time.AppendFormat(b, time.Stamp)
+	b = append(b, ' ')
+	b = append(b, h.ident...)
+	b = append(b, '[')
+	b = strconv.AppendInt(b, int64(os.Getpid()), 10)
+	b = append(b, "]: "...)
+	b = append(b, h.message...)
+	if h.msgID != "" {
+		b = append(b, " MESSAGE_ID="...)
+		b = append(b, h.msgID...)
+	}
+	for _, f := range h.rest {
+		b = append(b, ' ')
+		b = append(b, f.Key...)
+		b = append(b, '=')
+		b = appendTextValue(b, f.Value)
+	}
+	return b
+}
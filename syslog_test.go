@@ -0,0 +1,37 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSyslogWriter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tt := range []struct {
+		format SyslogFormat
+		want   string
+	}{
+		{RFC5424, `<12>1 2024-01-02T03:04:05.000000Z host app %d DISK_FULL [journal@32473 DEVICE="/dev/sda" NOTE="a \"b\" \]"] disk almost full`},
+		{RFC3164, `<12>Jan  2 03:04:05 app[%d]: disk almost full MESSAGE_ID=DISK_FULL DEVICE=/dev/sda NOTE="a \"b\" ]"`},
+	} {
+		buf := new(bytes.Buffer)
+		h, err := NewHandler(&Options{Identifier: "app"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.w = &syslogWriter{w: buf, format: tt.format, hostname: "host", now: func() time.Time { return now }}
+		r := slog.NewRecord(time.Time{}, slog.LevelWarn, "disk almost full", 0)
+		r.AddAttrs(slog.String("MESSAGE_ID", "DISK_FULL"), slog.String("DEVICE", "/dev/sda"), slog.String("NOTE", `a "b" ]`))
+		if err := h.Handle(context.TODO(), r); err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf(tt.want, os.Getpid()); buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package slogjournal
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SyslogOptions configure a [SyslogConn].
+type SyslogOptions struct {
+	// Format is the message format. The default is RFC5424.
+	Format SyslogFormat
+
+	// TLSConfig, if set, secures "tcp" connections with TLS as described in
+	// RFC 5425.
+	TLSConfig *tls.Config
+
+	// Hostname is sent as HOSTNAME in every message. The default is
+	// os.Hostname.
+	Hostname string
+
+	// DialTimeout limits connecting and writing a message. The default is
+	// five seconds.
+	DialTimeout time.Duration
+}
+
+// SyslogConn is an [io.Writer] that sends journal entries to a remote syslog
+// server, for environments that aggregate logs via syslog rather than
+// systemd-journal-remote. Pass it in Options.Mirrors, which receive every
+// entry in the native protocol; the Writer of a [Route] receives lines of
+// text and cannot be used:
+//
+//	c, err := slogjournal.DialSyslog("tcp", "logs.example.com:6514", &slogjournal.SyslogOptions{
+//		TLSConfig: &tls.Config{},
+//	})
+//	...
+//	h, err := slogjournal.NewHandler(&slogjournal.Options{Mirrors: []io.Writer{c}})
+//
+// Over "udp" every entry is sent as one datagram; over "tcp" messages are
+// framed by octet counting as described in RFC 6587. A broken connection is
+// redialed on the next Write. SyslogConn is safe for concurrent use.
+type SyslogConn struct {
+	remoteConn
+	w syslogWriter
+}
+
+// DialSyslog connects to the syslog server at addr. network is "udp" or
+// "tcp", or one of their variants such as "tcp6". If opts is nil, the
+// default options are used.
+func DialSyslog(network, addr string, opts *SyslogOptions) (*SyslogConn, error) {
+	var o SyslogOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Hostname == "" {
+		o.Hostname, _ = os.Hostname()
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = 5 * time.Second
+	}
+	c := &SyslogConn{
+		remoteConn: remoteConn{network: network, addr: addr, tlsConfig: o.TLSConfig, timeout: o.DialTimeout},
+		w:          syslogWriter{format: o.Format, hostname: o.Hostname},
+	}
+	if err := c.dial(); err != nil {
+		return nil, fmt.Errorf("slogjournal: dial syslog: %w", err)
+	}
+	return c, nil
+}
+
+// Write sends the journal entry p as a single syslog message. If the
+// connection was closed by the server, it is redialed once.
+func (c *SyslogConn) Write(p []byte) (int, error) {
+	fs, err := Decode(p)
+	if err != nil {
+		return 0, err
+	}
+	var msg []byte
+	if c.stream() {
+		// Reserve room for the octet count, see RFC 6587 section 3.4.1.
+		msg = make([]byte, 12)
+	}
+	start := len(msg)
+	msg = c.w.append(msg, fs, time.Now())
+	if c.stream() {
+		frame := strconv.AppendInt(nil, int64(len(msg)-start), 10)
+		frame = append(frame, ' ')
+		start -= len(frame)
+		copy(msg[start:], frame)
+		msg = msg[start:]
+	}
+
+	if err := c.write(msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection.
+func (c *SyslogConn) Close() error {
+	return c.close()
+}
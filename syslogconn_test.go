@@ -0,0 +1,89 @@
+package slogjournal
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSyslogConnTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	msgs := make(chan string, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			for {
+				n, err := r.ReadString(' ')
+				if err != nil {
+					break
+				}
+				size, _ := strconv.Atoi(strings.TrimSuffix(n, " "))
+				msg := make([]byte, size)
+				if _, err := io.ReadFull(r, msg); err != nil {
+					break
+				}
+				msgs <- string(msg)
+			}
+			conn.Close()
+		}
+	}()
+
+	c, err := DialSyslog("tcp", ln.Addr().String(), &SyslogOptions{Hostname: "host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	h, err := NewHandler(&Options{Identifier: "app", Mirrors: []io.Writer{c}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = io.Discard
+	logger := slog.New(h)
+	logger.Info("first", "KEY", "a b")
+	logger.Error("second")
+	for _, want := range []string{`KEY="a b"] first`, `<11>1 `} {
+		if got := <-msgs; !strings.Contains(got, want) {
+			t.Errorf("got %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestSyslogConnUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+	c, err := DialSyslog("udp", pc.LocalAddr().String(), &SyslogOptions{Format: RFC3164})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	h := &Handler{}
+	b := h.appendKV(nil, "MESSAGE", []byte("hello"))
+	b = h.appendKV(b, "PRIORITY", []byte("6"))
+	b = h.appendKV(b, "SYSLOG_IDENTIFIER", []byte("app"))
+	if _, err := c.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); !strings.HasPrefix(got, "<14>") || !strings.HasSuffix(got, "]: hello") {
+		t.Errorf("unexpected message %q", got)
+	}
+}
@@ -0,0 +1,138 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// TailBufferOptions configure [TailBufferMiddleware].
+type TailBufferOptions struct {
+	// Trigger is the level from which records flush the buffer. The
+	// default is slog.LevelWarn.
+	Trigger slog.Leveler
+
+	// Window is how long buffered records are kept. Records older than
+	// Window when the buffer is flushed are discarded. Zero keeps them
+	// for the lifetime of the context.
+	Window time.Duration
+
+	// MaxRecords is the number of records kept per context; older records
+	// are discarded. The default is 100.
+	MaxRecords int
+}
+
+// TailBufferMiddleware holds back records below the trigger level that are
+// logged with a context returned by [ContextWithTailBuffer], such as the
+// context of a request. They are only passed on, at their original levels
+// and before the triggering record, once a record at or above the trigger
+// level is logged with the same context. This keeps the journal quiet
+// until something goes wrong, and then shows what led up to it:
+//
+//	logger := slog.New(slogjournal.Chain(h, slogjournal.TailBufferMiddleware(nil)))
+//	...
+//	ctx := slogjournal.ContextWithTailBuffer(r.Context())
+//	logger.DebugContext(ctx, "cache miss", "key", key) // held back
+//	logger.ErrorContext(ctx, "query failed")           // sends both
+//
+// Records below the trigger level are buffered even if the wrapped handler
+// is not enabled for their level, so debug records show up next to the
+// error. Records logged without such a context pass through unchanged. If
+// opts is nil, the default options are used.
+func TailBufferMiddleware(opts *TailBufferOptions) Middleware {
+	var o TailBufferOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Trigger == nil {
+		o.Trigger = slog.LevelWarn
+	}
+	if o.MaxRecords <= 0 {
+		o.MaxRecords = 100
+	}
+	return func(h slog.Handler) slog.Handler {
+		return &tailBufferHandler{next: h, opts: o}
+	}
+}
+
+type tailBufferKey struct{}
+
+// ContextWithTailBuffer returns a copy of ctx holding a buffer for
+// [TailBufferMiddleware]. Records logged with it, or a context derived from
+// it, share the buffer.
+func ContextWithTailBuffer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tailBufferKey{}, &tailBuffer{})
+}
+
+// tailBuffer holds the records of one context.
+type tailBuffer struct {
+	mu      sync.Mutex
+	records []bufferedRecord
+}
+
+// bufferedRecord is a record held back together with the handler it is
+// to be passed to.
+type bufferedRecord struct {
+	h    slog.Handler
+	r    slog.Record
+	time time.Time
+}
+
+// tailBufferHandler is the handler returned by TailBufferMiddleware.
+type tailBufferHandler struct {
+	next slog.Handler
+	opts TailBufferOptions
+}
+
+func (h *tailBufferHandler) buffer(ctx context.Context) *tailBuffer {
+	if ctx == nil {
+		return nil
+	}
+	b, _ := ctx.Value(tailBufferKey{}).(*tailBuffer)
+	return b
+}
+
+func (h *tailBufferHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	if l < h.opts.Trigger.Level() && h.buffer(ctx) != nil {
+		return true
+	}
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *tailBufferHandler) Handle(ctx context.Context, r slog.Record) error {
+	b := h.buffer(ctx)
+	if b == nil {
+		return h.next.Handle(ctx, r)
+	}
+	now := time.Now()
+	b.mu.Lock()
+	if r.Level < h.opts.Trigger.Level() {
+		if len(b.records) == h.opts.MaxRecords {
+			b.records = append(b.records[:0], b.records[1:]...)
+		}
+		b.records = append(b.records, bufferedRecord{h.next, r.Clone(), now})
+		b.mu.Unlock()
+		return nil
+	}
+	records := b.records
+	b.records = nil
+	b.mu.Unlock()
+	for _, br := range records {
+		if h.opts.Window > 0 && now.Sub(br.time) > h.opts.Window {
+			continue
+		}
+		if err := br.h.Handle(ctx, br.r); err != nil {
+			return err
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *tailBufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &tailBufferHandler{next: h.next.WithAttrs(attrs), opts: h.opts}
+}
+
+func (h *tailBufferHandler) WithGroup(name string) slog.Handler {
+	return &tailBufferHandler{next: h.next.WithGroup(name), opts: h.opts}
+}
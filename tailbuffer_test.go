@@ -0,0 +1,73 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestTailBufferMiddleware(t *testing.T) {
+	ew := &entryWriter{}
+	h, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = ew
+	l := slog.New(Chain(h, TailBufferMiddleware(&TailBufferOptions{MaxRecords: 2})))
+
+	ctx := ContextWithTailBuffer(context.Background())
+	l.InfoContext(context.Background(), "unbuffered")
+	l.DebugContext(ctx, "dropped")
+	l.With("K", "v").DebugContext(ctx, "debug")
+	l.InfoContext(ctx, "info")
+	if got := ew.messages(); len(got) != 1 {
+		t.Fatalf("records not held back: %q", got)
+	}
+	l.ErrorContext(ctx, "failed")
+	l.InfoContext(ctx, "after")
+	l.WarnContext(ctx, "warned")
+
+	want := []string{"unbuffered", "debug", "info", "failed", "after", "warned"}
+	got := ew.messages()
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got, want)
+			break
+		}
+	}
+	fs := parseFields(ew.entries[1])
+	var prio, k string
+	for _, f := range fs {
+		switch f.Key {
+		case "PRIORITY":
+			prio = string(f.Value)
+		case "K":
+			k = string(f.Value)
+		}
+	}
+	if prio != "7" || k != "v" {
+		t.Errorf("flushed record has PRIORITY=%q K=%q, want 7 and v", prio, k)
+	}
+}
+
+func TestTailBufferWindow(t *testing.T) {
+	ew := &entryWriter{}
+	h, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = ew
+	l := slog.New(Chain(h, TailBufferMiddleware(&TailBufferOptions{Window: 50 * time.Millisecond})))
+	ctx := ContextWithTailBuffer(context.Background())
+	l.InfoContext(ctx, "stale")
+	time.Sleep(100 * time.Millisecond)
+	l.InfoContext(ctx, "fresh")
+	l.WarnContext(ctx, "warned")
+	if got := ew.messages(); len(got) != 2 || got[0] != "fresh" {
+		t.Errorf("got %q, want fresh and warned", got)
+	}
+}
@@ -0,0 +1,203 @@
+package slogjournal
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// LogTarget names where a [Handler] sends its records. The values match
+// those of the LogTarget property of [org.freedesktop.LogControl1], so
+// programs implementing that interface can pass them through unchanged.
+//
+// [org.freedesktop.LogControl1]: https://www.freedesktop.org/software/systemd/man/latest/org.freedesktop.LogControl1.html
+type LogTarget string
+
+const (
+	// LogTargetJournal sends records to the journal. This is the default.
+	LogTargetJournal LogTarget = "journal"
+	// LogTargetConsole writes the message of each record to standard error.
+	LogTargetConsole LogTarget = "console"
+	// LogTargetKmsg writes the message of each record to the kernel log
+	// buffer, /dev/kmsg.
+	LogTargetKmsg LogTarget = "kmsg"
+	// LogTargetNull discards all records.
+	LogTargetNull LogTarget = "null"
+)
+
+// logTargets holds the target selection shared by a handler and all
+// handlers derived from it.
+type logTargets struct {
+	current atomic.Value // LogTarget
+	console io.Writer
+
+	mu   sync.Mutex
+	kmsg io.Writer
+}
+
+func newLogTargets() *logTargets {
+	t := &logTargets{console: os.Stderr}
+	t.current.Store(LogTargetJournal)
+	return t
+}
+
+// LogTarget returns the target h currently sends records to.
+func (h *Handler) LogTarget() LogTarget {
+	if h.targets == nil {
+		return LogTargetJournal
+	}
+	return h.targets.current.Load().(LogTarget)
+}
+
+// SetLogTarget switches h, and every handler derived from it or sharing its
+// parent, to target t. This is what `systemctl service-log-target` asks for
+// through the LogControl1 interface. Switching to LogTargetKmsg fails if
+// /dev/kmsg cannot be opened.
+func (h *Handler) SetLogTarget(t LogTarget) error {
+	if h.targets == nil {
+		return fmt.Errorf("slogjournal: handler does not support switching log targets")
+	}
+	switch t {
+	case LogTargetJournal, LogTargetConsole, LogTargetNull:
+	case LogTargetKmsg:
+		if _, err := h.targets.openKmsg(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("slogjournal: unsupported log target %q", t)
+	}
+	h.targets.current.Store(t)
+	return nil
+}
+
+// openKmsg returns /dev/kmsg, opening it on first use.
+func (t *logTargets) openKmsg() (io.Writer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.kmsg == nil {
+		f, err := os.OpenFile("/dev/kmsg", os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return nil, err
+		}
+		t.kmsg = f
+	}
+	return t.kmsg, nil
+}
+
+// write sends the encoded record b, logged at level l, to the current
+// target, or to the matching Options.Routes.
+func (h *Handler) write(l slog.Level, b []byte) error {
+	if d := h.opts.DebugWriter; d != nil {
+		_, _ = d.Write(b)
+	}
+	if h.recent != nil {
+		h.recent.add(b)
+	}
+	for _, m := range h.opts.Mirrors {
+		if _, err := m.Write(b); err != nil && h.stats != nil {
+			h.stats.setLastError(err)
+		}
+	}
+	n, err := h.writeTarget(l, b)
+	h.countWrite(n, err)
+	return err
+}
+
+// countWrite records the outcome of sending n bytes of a record.
+func (h *Handler) countWrite(n int, err error) {
+	if h.stats != nil {
+		h.stats.bytes.Add(uint64(n))
+		if err != nil {
+			h.stats.recordError(err)
+		}
+	}
+}
+
+// minVectoredSize is the size of the preformatted attributes from which
+// sending them as a separate buffer is cheaper than copying them.
+const minVectoredSize = 1024
+
+// canWriteVectored reports whether records can be sent with writeVectored.
+func (h *Handler) canWriteVectored() bool {
+	_, ok := h.w.(buffersWriter)
+	return ok && h.writesDirect()
+}
+
+// writesDirect reports whether records go straight to the journal and no
+// option needs to see or rewrite the whole record.
+func (h *Handler) writesDirect() bool {
+	return h.opts.DebugWriter == nil && h.recent == nil && len(h.opts.Mirrors) == 0 && len(h.opts.Routes) == 0 &&
+		h.opts.DuplicateKeys == DuplicateKeyAllow && !h.opts.SortFields && h.opts.MaxRecordBytes <= 0 &&
+		h.LogTarget() == LogTargetJournal
+}
+
+// writeVectored sends the record made up of bufs to the journal without
+// concatenating them, see canWriteVectored.
+func (h *Handler) writeVectored(bufs ...[]byte) error {
+	n, err := h.w.(buffersWriter).WriteBuffers(bufs)
+	h.countWrite(n, err)
+	return err
+}
+
+func (h *Handler) writeTarget(l slog.Level, b []byte) (int, error) {
+	t := h.LogTarget()
+	if t == LogTargetJournal && len(h.opts.Routes) > 0 {
+		return h.writeRoutes(l, b)
+	}
+	return h.writeTo(t, b)
+}
+
+// writeTo writes b to target t.
+func (h *Handler) writeTo(t LogTarget, b []byte) (int, error) {
+	switch t {
+	case LogTargetConsole:
+		return writeText(h.targets.console, b, false)
+	case LogTargetKmsg:
+		h.targets.mu.Lock()
+		w := h.targets.kmsg
+		h.targets.mu.Unlock()
+		return writeText(w, b, true)
+	case LogTargetNull:
+		return 0, nil
+	}
+	if a, ok := h.w.(*asyncWriter); ok {
+		// The queue counts the bytes once they are sent.
+		_, err := a.Write(b)
+		return 0, err
+	}
+	return h.w.Write(b)
+}
+
+// writeText writes the MESSAGE field of the encoded record b to w as a single
+// line. With kmsg set the line is prefixed with the priority and identifier
+// in the format the kernel log buffer expects.
+func writeText(w io.Writer, b []byte, kmsg bool) (int, error) {
+	var msg, prio, ident []byte
+	for _, f := range parseFields(b) {
+		switch f.Key {
+		case "MESSAGE":
+			msg = f.Value
+		case "PRIORITY":
+			prio = f.Value
+		case "SYSLOG_IDENTIFIER":
+			ident = f.Value
+		}
+	}
+	var line []byte
+	if kmsg {
+		line = append(line, '<')
+		line = append(line, prio...)
+		line = append(line, '>')
+		line = append(line, ident...)
+		line = append(line, '[')
+		line = strconv.AppendInt(line, int64(os.Getpid()), 10)
+		line = append(line, "]: "...)
+	}
+	line = append(line, msg...)
+	line = append(line, '\n')
+	return w.Write(line)
+}
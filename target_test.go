@@ -0,0 +1,71 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLogTarget(t *testing.T) {
+	journal := new(bytes.Buffer)
+	console := new(bytes.Buffer)
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = journal
+	handler.targets.console = console
+	child := handler.WithGroup("G")
+
+	if handler.LogTarget() != LogTargetJournal {
+		t.Errorf("expected journal target, got %q", handler.LogTarget())
+	}
+
+	if err := handler.SetLogTarget(LogTargetConsole); err != nil {
+		t.Fatal(err)
+	}
+	_ = child.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "to the console", 0))
+	if journal.Len() != 0 {
+		t.Errorf("unexpected journal output %q", journal.Bytes())
+	}
+	if console.String() != "to the console\n" {
+		t.Errorf("unexpected console output %q", console.Bytes())
+	}
+
+	console.Reset()
+	if err := handler.SetLogTarget(LogTargetNull); err != nil {
+		t.Fatal(err)
+	}
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "nowhere", 0))
+	if journal.Len() != 0 || console.Len() != 0 {
+		t.Error("expected no output for the null target")
+	}
+
+	if err := handler.SetLogTarget("syslog"); err == nil {
+		t.Error("expected error for unsupported target")
+	}
+
+	if err := handler.SetLogTarget(LogTargetJournal); err != nil {
+		t.Fatal(err)
+	}
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Time{}, slog.LevelInfo, "journal", 0))
+	if journal.Len() == 0 {
+		t.Error("expected journal output")
+	}
+}
+
+func TestWriteTextKmsg(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h := &Handler{}
+	b := h.appendKV(nil, "MESSAGE", []byte("hello"))
+	b = h.appendKV(b, "PRIORITY", []byte("3"))
+	b = h.appendKV(b, "SYSLOG_IDENTIFIER", []byte("test"))
+	if _, err := writeText(buf, b, true); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("<3>test[")) || !bytes.HasSuffix(buf.Bytes(), []byte("]: hello\n")) {
+		t.Errorf("unexpected kmsg line %q", buf.Bytes())
+	}
+}
@@ -0,0 +1,70 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// renderTemplate replaces every {KEY} in tmpl with the value lookup returns
+// for KEY. Placeholders lookup knows nothing about are kept.
+func renderTemplate(tmpl string, lookup func(key string) (string, bool)) string {
+	if !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(tmpl[:start])
+		if v, ok := lookup(tmpl[start+1 : end]); ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(tmpl[start : end+1])
+		}
+		tmpl = tmpl[end+1:]
+	}
+	b.WriteString(tmpl)
+	return b.String()
+}
+
+// attrLookup returns a lookup function for renderTemplate finding the
+// value of the first attribute with the given key.
+func attrLookup(attrs []slog.Attr) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		for _, a := range attrs {
+			if a.Key == key {
+				return a.Value.Resolve().String(), true
+			}
+		}
+		return "", false
+	}
+}
+
+// renderMessage renders the message template msg of a record with attrs,
+// see Options.MessageTemplates. Keys not among attrs are looked up in the
+// fields added with WithAttrs, by their full field name.
+func (h *Handler) renderMessage(msg string, attrs []slog.Attr) string {
+	find := attrLookup(attrs)
+	var pre []Field
+	return renderTemplate(msg, func(key string) (string, bool) {
+		if v, ok := find(key); ok {
+			return v, true
+		}
+		if pre == nil {
+			pre = parseFields(h.preformatted)
+		}
+		for _, f := range pre {
+			if f.Key == key {
+				return string(f.Value), true
+			}
+		}
+		return "", false
+	})
+}
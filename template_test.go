@@ -0,0 +1,37 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestMessageTemplates(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(&Options{MessageTemplates: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	logger := slog.New(h).With("METHOD", "password")
+
+	logger.Info("user {USER_ID} logged in via {METHOD} {UNKNOWN}", "USER_ID", 42)
+	m, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["MESSAGE"] != "user 42 logged in via password {UNKNOWN}" {
+		t.Errorf("unexpected message %q", m["MESSAGE"])
+	}
+	if m["USER_ID"] != "42" || m["METHOD"] != "password" {
+		t.Errorf("expected fields to be kept, got %v", m)
+	}
+
+	// Without the option messages are sent unchanged.
+	buf.Reset()
+	h.opts.MessageTemplates = false
+	slog.New(h).Info("user {USER_ID}", "USER_ID", 42)
+	if m, _ := deserializeKeyValue(buf); m["MESSAGE"] != "user {USER_ID}" {
+		t.Errorf("unexpected message %q", m["MESSAGE"])
+	}
+}
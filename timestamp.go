@@ -0,0 +1,64 @@
+package slogjournal
+
+import (
+	"strconv"
+	"time"
+)
+
+// TimestampFormat selects how a record's time is formatted in the
+// SYSLOG_TIMESTAMP field.
+type TimestampFormat int
+
+const (
+	// TimestampSyslog uses the traditional syslog format of RFC 3164 with
+	// microseconds added, such as "Jan  2 15:04:05.000000", in local time.
+	// This is what journald itself stores in SYSLOG_TIMESTAMP for messages
+	// received via syslog, apart from the fraction, which keeps the order
+	// of records forwarded by transports such as [SyslogConn] that take
+	// the time from this field.
+	TimestampSyslog TimestampFormat = iota
+	// TimestampRFC3339 uses RFC 3339 with microseconds, such as
+	// "2006-01-02T15:04:05.000000Z07:00".
+	TimestampRFC3339
+	// TimestampUnixMicro uses the number of microseconds since the Unix
+	// epoch, like journald's own __REALTIME_TIMESTAMP.
+	TimestampUnixMicro
+)
+
+// rfc3339Micro is RFC 3339 with a fixed number of fractional digits.
+const rfc3339Micro = "2006-01-02T15:04:05.000000Z07:00"
+
+// appendTimestamp appends t to b in format f.
+func appendTimestamp(b []byte, t time.Time, f TimestampFormat) []byte {
+	switch f {
+	case TimestampRFC3339:
+		return t.AppendFormat(b, rfc3339Micro)
+	case TimestampUnixMicro:
+		return strconv.AppendInt(b, t.UnixMicro(), 10)
+	}
+	return t.Local().AppendFormat(b, time.StampMicro)
+}
+
+// ParseSyslogTimestamp parses a SYSLOG_TIMESTAMP value in any of the
+// formats a [TimestampFormat] selects. Timestamps in the traditional syslog
+// format carry no year; the year is chosen such that the time is not more
+// than a day in the future.
+func ParseSyslogTimestamp(s string) (time.Time, bool) {
+	if us, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMicro(us), true
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	// time.Stamp accepts a fraction of a second as well.
+	t, err := time.ParseInLocation(time.Stamp, s, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	now := time.Now()
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, true
+}
@@ -0,0 +1,65 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestTimestampFormat(t *testing.T) {
+	tm := time.Date(2024, time.January, 2, 3, 4, 5, 678901000, time.UTC)
+	r := slog.NewRecord(tm, slog.LevelInfo, "hello", 0)
+	for _, tt := range []struct {
+		format TimestampFormat
+		want   string
+	}{
+		{TimestampSyslog, tm.Local().Format(time.StampMicro)},
+		{TimestampRFC3339, "2024-01-02T03:04:05.678901Z"},
+		{TimestampUnixMicro, "1704164645678901"},
+	} {
+		buf := new(bytes.Buffer)
+		h, err := NewHandler(&Options{Level: slog.LevelInfo, TimestampFormat: tt.format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.w = buf
+		if err := h.Handle(context.TODO(), r); err != nil {
+			t.Fatal(err)
+		}
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := kv["SYSLOG_TIMESTAMP"]
+		if got != tt.want {
+			t.Errorf("format %d: SYSLOG_TIMESTAMP=%q, want %q", tt.format, got, tt.want)
+		}
+		parsed, ok := ParseSyslogTimestamp(got)
+		if !ok {
+			t.Fatalf("ParseSyslogTimestamp(%q) failed", got)
+		}
+		if tt.format == TimestampSyslog {
+			// The year is not part of the format.
+			parsed = parsed.AddDate(tm.Year()-parsed.Year(), 0, 0)
+		}
+		if !parsed.Equal(tm.Truncate(time.Microsecond)) {
+			t.Errorf("ParseSyslogTimestamp(%q) = %v, want %v", got, parsed, tm)
+		}
+	}
+}
+
+func TestParseSyslogTimestampYear(t *testing.T) {
+	now := time.Now()
+	got, ok := ParseSyslogTimestamp(now.Format(time.Stamp))
+	if !ok {
+		t.Fatal("ParseSyslogTimestamp failed")
+	}
+	if d := now.Sub(got); d < 0 || d > time.Second {
+		t.Errorf("ParseSyslogTimestamp = %v, want about %v", got, now)
+	}
+	if _, ok := ParseSyslogTimestamp("yesterday"); ok {
+		t.Error("expected invalid timestamp to fail")
+	}
+}
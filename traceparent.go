@@ -0,0 +1,69 @@
+package slogjournal
+
+import (
+	"context"
+	"strings"
+)
+
+// TraceparentKey is the key of an attribute holding a [W3C traceparent]
+// header value. The handler recognizes it in records, and in contexts
+// returned by [ContextWithTraceparent], and sends the trace context it
+// describes as the TRACE_ID, SPAN_ID and TRACE_FLAGS fields, so entries of
+// services that only have the header value can still be correlated.
+//
+// [W3C traceparent]: https://www.w3.org/TR/trace-context/#traceparent-header
+const TraceparentKey = "traceparent"
+
+type traceparentKey struct{}
+
+// ContextWithTraceparent returns a copy of ctx carrying the traceparent
+// header value tp, for example as received in an HTTP request. Records
+// logged with the returned context get the fields described for
+// [TraceparentKey].
+func ContextWithTraceparent(ctx context.Context, tp string) context.Context {
+	return context.WithValue(ctx, traceparentKey{}, tp)
+}
+
+// traceparent returns the traceparent of a record, which is attr, the value
+// of its traceparent attribute, if set and otherwise carried by ctx.
+func traceparent(ctx context.Context, attr string) string {
+	if attr == "" && ctx != nil {
+		attr, _ = ctx.Value(traceparentKey{}).(string)
+	}
+	return attr
+}
+
+// parseTraceparent splits a traceparent header value into its trace ID,
+// span ID and flags. It reports false if tp is malformed or describes an
+// invalid trace context.
+func parseTraceparent(tp string) (traceID, spanID, flags string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(tp), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", "", false
+	}
+	// Version 00 has exactly four parts; later versions may add more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", "", false
+	}
+	traceID, spanID, flags = parts[1], parts[2], parts[3]
+	if !isHex(parts[0]) || len(traceID) != 32 || !isHex(traceID) || allZero(traceID) ||
+		len(spanID) != 16 || !isHex(spanID) || allZero(spanID) ||
+		len(flags) != 2 || !isHex(flags) {
+		return "", "", "", false
+	}
+	return traceID, spanID, flags, true
+}
+
+// isHex reports whether s consists of lowercase hexadecimal digits.
+func isHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func allZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
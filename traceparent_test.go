@@ -0,0 +1,61 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestTraceparent(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	buf := new(bytes.Buffer)
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = buf
+	logger := slog.New(h)
+
+	check := func(name string) {
+		t.Helper()
+		m, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m["TRACE_ID"] != "4bf92f3577b34da6a3ce929d0e0e4736" || m["SPAN_ID"] != "00f067aa0ba902b7" || m["TRACE_FLAGS"] != "01" {
+			t.Errorf("%s: unexpected fields %v", name, m)
+		}
+		buf.Reset()
+	}
+
+	logger.Info("attr", TraceparentKey, tp)
+	check("attr")
+	logger.WithGroup("G").InfoContext(ContextWithTraceparent(context.Background(), tp), "context")
+	check("context")
+
+	logger.Info("invalid", TraceparentKey, "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	if m, _ := deserializeKeyValue(buf); m["TRACE_ID"] != "" {
+		t.Errorf("unexpected fields %v", m)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	for _, tc := range []struct {
+		tp string
+		ok bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4bf92f3577b34da6-00f067aa0ba902b7-01", false},
+		{"garbage", false},
+	} {
+		if _, _, _, ok := parseTraceparent(tc.tp); ok != tc.ok {
+			t.Errorf("parseTraceparent(%q) = %v, want %v", tc.tp, ok, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package slogjournal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"path/filepath"
+	"strings"
+)
+
+// varlinkSocket returns the path of the varlink socket of the journal that
+// listens on the native protocol socket at addr.
+func varlinkSocket(addr string) string {
+	return filepath.Join(filepath.Dir(addr), "io.systemd.journal")
+}
+
+// JournalControl is a client for the io.systemd.Journal varlink interface
+// of journald, available since systemd 254, which controls journald beyond
+// sending it entries. Every call opens a new connection, so a
+// JournalControl is safe for concurrent use.
+type JournalControl struct {
+	path string
+}
+
+// NewJournalControl returns a JournalControl for the journal namespace, or
+// the default journal if namespace is empty.
+func NewJournalControl(namespace string) *JournalControl {
+	return &JournalControl{path: varlinkSocket(journalSocket(namespace))}
+}
+
+// JournalControl returns a JournalControl for the journal h sends records
+// to, or the first of them, see Options.ExtraNamespaces. It fails if h
+// does not write to a journal socket, for example on platforms without a
+// journal, or uses an abstract socket address, which has no varlink
+// socket next to it.
+func (h *Handler) JournalControl() (*JournalControl, error) {
+	ws := journalWriters(h.w)
+	if len(ws) == 0 {
+		return nil, fmt.Errorf("slogjournal: handler does not write to a journal: %w", errors.ErrUnsupported)
+	}
+	addr := ws[0].addr.Name
+	if strings.HasPrefix(addr, "@") {
+		return nil, fmt.Errorf("slogjournal: no varlink socket for the journal at abstract address %q: %w", addr, errors.ErrUnsupported)
+	}
+	return &JournalControl{path: varlinkSocket(addr)}, nil
+}
+
+// Synchronize asks journald to write all entries it has received so far to
+// disk and waits until it has done so, like journalctl --sync.
+func (c *JournalControl) Synchronize(ctx context.Context) error {
+	return varlinkCall(ctx, c.path, "io.systemd.Journal.Synchronize", nil, nil)
+}
+
+// Rotate asks journald to archive its journal files and start new ones,
+// like journalctl --rotate.
+func (c *JournalControl) Rotate(ctx context.Context) error {
+	return varlinkCall(ctx, c.path, "io.systemd.Journal.Rotate", nil, nil)
+}
+
+// FlushToVar asks journald to move the entries stored in /run/log/journal
+// to /var/log/journal, like journalctl --flush.
+func (c *JournalControl) FlushToVar(ctx context.Context) error {
+	return varlinkCall(ctx, c.path, "io.systemd.Journal.FlushToVar", nil, nil)
+}
+
+// RelinquishVar asks journald to stop writing to /var/log/journal, for
+// example before it is unmounted, like journalctl --relinquish-var.
+func (c *JournalControl) RelinquishVar(ctx context.Context) error {
+	return varlinkCall(ctx, c.path, "io.systemd.Journal.RelinquishVar", nil, nil)
+}
+
+// VarlinkInfo describes a varlink service.
+type VarlinkInfo struct {
+	Vendor     string   `json:"vendor"`
+	Product    string   `json:"product"`
+	Version    string   `json:"version"`
+	URL        string   `json:"url"`
+	Interfaces []string `json:"interfaces"`
+}
+
+// Info returns the description of journald's varlink service, including
+// its version and the interfaces it implements, so callers can find out
+// which methods are available.
+func (c *JournalControl) Info(ctx context.Context) (VarlinkInfo, error) {
+	var info VarlinkInfo
+	err := varlinkCall(ctx, c.path, "org.varlink.service.GetInfo", nil, &info)
+	return info, err
+}
+
+// varlinkCall calls method with the given parameters on the varlink service
+// listening at path and waits for its reply, whose parameters are decoded
+// into out if it is not nil. Errors for services or methods that do not
+// exist wrap [errors.ErrUnsupported].
+func varlinkCall(ctx context.Context, path, method string, params, out any) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("varlink: %w: %w", err, errors.ErrUnsupported)
+		}
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if params == nil {
+		params = struct{}{}
+	}
+	req, err := json.Marshal(struct {
+		Method     string `json:"method"`
+		Parameters any    `json:"parameters"`
+	}{method, params})
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(append(req, 0)); err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	b, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+	var reply struct {
+		Error      string          `json:"error"`
+		Parameters json.RawMessage `json:"parameters"`
+	}
+	if err := json.Unmarshal(b[:len(b)-1], &reply); err != nil {
+		return err
+	}
+	switch reply.Error {
+	case "":
+	case "org.varlink.service.InterfaceNotFound", "org.varlink.service.MethodNotFound", "org.varlink.service.MethodNotImplemented":
+		return fmt.Errorf("varlink: %s: %s: %w", method, reply.Error, errors.ErrUnsupported)
+	default:
+		return errors.New("varlink: " + method + ": " + reply.Error)
+	}
+	if out != nil && len(reply.Parameters) > 0 {
+		return json.Unmarshal(reply.Parameters, out)
+	}
+	return nil
+}
+
+// ctxErr returns ctx's error if ctx is done, since closing the connection
+// when it is cancelled makes err meaningless, and err otherwise.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package slogjournal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// serveVarlink answers varlink calls on a socket at path with the reply
+// returned by handle for the method called.
+func serveVarlink(t *testing.T, path string, handle func(method string) string) {
+	t.Helper()
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			b, err := bufio.NewReader(conn).ReadBytes(0)
+			if err == nil {
+				var req struct{ Method string }
+				_ = json.Unmarshal(b[:len(b)-1], &req)
+				if reply := handle(req.Method); reply != "" {
+					_, _ = conn.Write([]byte(reply + "\x00"))
+				}
+			}
+			conn.Close()
+		}
+	}()
+}
+
+func TestJournalControl(t *testing.T) {
+	dir := t.TempDir()
+	called := make(chan string, 10)
+	serveVarlink(t, filepath.Join(dir, "io.systemd.journal"), func(method string) string {
+		called <- method
+		switch method {
+		case "org.varlink.service.GetInfo":
+			return `{"parameters":{"vendor":"The systemd Project","product":"systemd (systemd-journald)","version":"256","url":"https://systemd.io/","interfaces":["io.systemd.Journal","org.varlink.service"]}}`
+		case "io.systemd.Journal.RelinquishVar":
+			return `{"error":"org.varlink.service.MethodNotFound","parameters":{"method":"io.systemd.Journal.RelinquishVar"}}`
+		case "io.systemd.Journal.FlushToVar":
+			return `{"error":"io.systemd.Journal.NotSupportedByNamespace"}`
+		}
+		return "{}"
+	})
+
+	h, err := NewHandler(&Options{Addr: filepath.Join(dir, "socket")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := h.JournalControl()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	info, err := c.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "256" || len(info.Interfaces) != 2 {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if err := c.Rotate(ctx); err != nil {
+		t.Errorf("Rotate: %v", err)
+	}
+	if err := c.RelinquishVar(ctx); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("RelinquishVar: got %v, want ErrUnsupported", err)
+	}
+	if err := c.FlushToVar(ctx); err == nil || errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("FlushToVar: got %v, want service error", err)
+	}
+	for _, want := range []string{"org.varlink.service.GetInfo", "io.systemd.Journal.Rotate", "io.systemd.Journal.RelinquishVar", "io.systemd.Journal.FlushToVar"} {
+		if m := <-called; m != want {
+			t.Errorf("called %q, want %q", m, want)
+		}
+	}
+
+	missing := &JournalControl{path: filepath.Join(dir, "missing")}
+	if err := missing.Synchronize(ctx); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Synchronize without socket: got %v, want ErrUnsupported", err)
+	}
+}
+
+func TestJournalControlCancel(t *testing.T) {
+	dir := t.TempDir()
+	serveVarlink(t, filepath.Join(dir, "io.systemd.journal"), func(string) string {
+		time.Sleep(time.Second)
+		return ""
+	})
+	c := &JournalControl{path: filepath.Join(dir, "io.systemd.journal")}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Synchronize(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,87 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WatchdogInterval returns the watchdog timeout systemd configured for the
+// service with WatchdogSec=, as passed in $WATCHDOG_USEC. It reports false
+// if the watchdog is disabled or meant for another process.
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// StartWatchdog keeps the systemd watchdog from firing by sending WATCHDOG=1
+// at half the configured timeout, see [WatchdogInterval]. If healthy is not
+// nil, it is called first and the watchdog is only notified if it returns
+// nil, so a stuck program is restarted. Every failure is logged to logger at
+// LevelAlert: an unhealthy program, a failed notification, and a
+// notification sent so long after the previous one that the watchdog was
+// about to fire, which happens when the process is starved of CPU or the
+// health check is slow.
+//
+// It reports false, and does nothing, if the watchdog is not enabled. The
+// returned function stops notifying the watchdog.
+func StartWatchdog(logger *slog.Logger, healthy func() error) (stop func(), ok bool) {
+	timeout, ok := WatchdogInterval()
+	if !ok {
+		return func() {}, false
+	}
+	t := time.NewTicker(timeout / 2)
+	done := make(chan struct{})
+	go func() {
+		// last is when WATCHDOG=1 was last sent. The ticker's timestamps
+		// stay evenly spaced when the goroutine is starved, so lateness is
+		// measured with the clock instead.
+		last := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				if !petWatchdog(logger, healthy) {
+					continue
+				}
+				now := time.Now()
+				if late := now.Sub(last); late > timeout*3/4 {
+					logger.Log(context.Background(), LevelAlert, "watchdog was almost missed",
+						slog.Duration("WATCHDOG_DELAY", late), slog.Duration("WATCHDOG_TIMEOUT", timeout))
+				}
+				last = now
+			}
+		}
+	}()
+	return sync.OnceFunc(func() {
+		t.Stop()
+		close(done)
+	}), true
+}
+
+// petWatchdog notifies the watchdog if the program is healthy. It reports
+// whether the notification was sent.
+func petWatchdog(logger *slog.Logger, healthy func() error) bool {
+	ctx := context.Background()
+	if healthy != nil {
+		if err := healthy(); err != nil {
+			logger.Log(ctx, LevelAlert, "health check failed, not notifying watchdog", slog.Any("ERROR", err))
+			return false
+		}
+	}
+	if err := Notify("WATCHDOG=1"); err != nil {
+		logger.Log(ctx, LevelAlert, "failed to notify watchdog", slog.Any("ERROR", err))
+		return false
+	}
+	return true
+}
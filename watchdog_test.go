@@ -0,0 +1,24 @@
+package slogjournal
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	if _, ok := WatchdogInterval(); ok {
+		t.Error("expected the watchdog to be disabled")
+	}
+	t.Setenv("WATCHDOG_USEC", "2000000")
+	t.Setenv("WATCHDOG_PID", "1")
+	if _, ok := WatchdogInterval(); ok && os.Getpid() != 1 {
+		t.Error("expected the watchdog to be meant for another process")
+	}
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if d, ok := WatchdogInterval(); !ok || d != 2*time.Second {
+		t.Errorf("unexpected interval %v, %v", d, ok)
+	}
+}
@@ -0,0 +1,89 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartWatchdog(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	if _, ok := StartWatchdog(slog.Default(), nil); ok {
+		t.Error("expected the watchdog to be disabled")
+	}
+
+	conn := listenNotify(t)
+	t.Setenv("WATCHDOG_USEC", "40000")
+	t.Setenv("WATCHDOG_PID", "")
+	w := &entryWriter{}
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = w
+
+	var fail atomic.Bool
+	stop, ok := StartWatchdog(slog.New(h), func() error {
+		if fail.Load() {
+			return errors.New("stuck")
+		}
+		return nil
+	})
+	if !ok {
+		t.Fatal("expected the watchdog to be enabled")
+	}
+	defer stop()
+	if s := readNotify(t, conn); s != "WATCHDOG=1" {
+		t.Errorf("unexpected state %q", s)
+	}
+
+	fail.Store(true)
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(w.messages(), "health check failed, not notifying watchdog") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected an alert, got %q", w.messages())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+}
+
+func TestWatchdogLate(t *testing.T) {
+	conn := listenNotify(t)
+	t.Setenv("WATCHDOG_USEC", "40000")
+	t.Setenv("WATCHDOG_PID", "")
+	w := &entryWriter{}
+	h, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = w
+
+	// The second check stalls the goroutine past most of the timeout, while
+	// the ticker keeps ticking on schedule.
+	var calls atomic.Int32
+	stop, ok := StartWatchdog(slog.New(h), func() error {
+		if calls.Add(1) == 2 {
+			time.Sleep(35 * time.Millisecond)
+		}
+		return nil
+	})
+	if !ok {
+		t.Fatal("expected the watchdog to be enabled")
+	}
+	defer stop()
+	readNotify(t, conn)
+	readNotify(t, conn)
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(w.messages(), "watchdog was almost missed") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected an alert, got %q", w.messages())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,90 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// lineWriter splits the bytes written to it into lines and passes each
+// complete line to emit. It is safe for concurrent use.
+type lineWriter struct {
+	emit func(line []byte) error
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := w.buf[:i]
+		w.buf = w.buf[i+1:]
+		if err := w.emit(bytes.TrimSuffix(line, []byte{'\r'})); err != nil {
+			return len(p), err
+		}
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := w.buf
+	w.buf = nil
+	return w.emit(bytes.TrimSuffix(line, []byte{'\r'}))
+}
+
+// PriorityWriter is an [io.Writer] that turns every line written to it into
+// a record at a fixed level, so the output of subprocesses or legacy code can
+// be sent to the journal with the right priority.
+// It is safe for concurrent use.
+type PriorityWriter struct {
+	lineWriter
+	h     slog.Handler
+	level slog.Level
+}
+
+// NewPriorityWriter returns a PriorityWriter that passes each line to h as
+// the message of a record at level.
+func NewPriorityWriter(h slog.Handler, level slog.Level) *PriorityWriter {
+	w := &PriorityWriter{h: h, level: level}
+	w.emit = w.emitLine
+	return w
+}
+
+// Write logs every complete line in p. An incomplete trailing line is kept
+// until the rest of it is written or Close is called.
+func (w *PriorityWriter) Write(p []byte) (int, error) {
+	return w.lineWriter.Write(p)
+}
+
+// Close logs any incomplete line that is still buffered.
+func (w *PriorityWriter) Close() error {
+	return w.lineWriter.Close()
+}
+
+func (w *PriorityWriter) emitLine(line []byte) error {
+	ctx := context.Background()
+	if !w.h.Enabled(ctx, w.level) {
+		return nil
+	}
+	return w.h.Handle(ctx, slog.NewRecord(time.Now(), w.level, string(line), 0))
+}
+
+var _ io.WriteCloser = &PriorityWriter{}
@@ -0,0 +1,41 @@
+package slogjournal
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+func TestPriorityWriter(t *testing.T) {
+	ew := &entryWriter{}
+	h, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.w = ew
+
+	w := NewPriorityWriter(h, slog.LevelWarn)
+	fmt.Fprint(w, "first line\nsecond ")
+	fmt.Fprint(w, "line\r\nunterminated")
+	if len(ew.entries) != 2 {
+		t.Fatalf("expected 2 entries before Close, got %d", len(ew.entries))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"first line", "second line", "unterminated"}
+	if len(ew.entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(ew.entries))
+	}
+	for i, e := range ew.entries {
+		kv, err := deserializeKeyValue(bytes.NewReader(e))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kv["MESSAGE"] != want[i] || kv["PRIORITY"] != "4" {
+			t.Errorf("entry %d: unexpected fields %v", i, kv)
+		}
+	}
+}